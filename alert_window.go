@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"log"
 	"net/url"
+	"strings"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/borgmon/focus-breaker/pkg/audio"
 	"github.com/borgmon/focus-breaker/pkg/models"
@@ -17,12 +19,18 @@ import (
 	"golang.design/x/hotkey"
 )
 
+// maxDialInRows is how many dial-in numbers the alert shows; invitations often list dozens
+const maxDialInRows = 3
+
 type AlertWindow struct {
 	window          fyne.Window
 	app             fyne.App
 	event           models.Event
+	missed          []models.Event // Events of a combined missed-alerts window, empty for a single alert
+	missedAt        time.Time      // When the missed alerts were found
 	snoozeMinutes   int
 	holdTimeSeconds int
+	openMeeting     func(models.Event, models.Conference)
 	onClose         func()
 	onSnooze        func()
 
@@ -37,25 +45,36 @@ type AlertWindow struct {
 	stopMonitoring chan struct{}
 }
 
-func NewAlertWindow(app fyne.App, event models.Event, snoozeMinutes int, holdTimeSeconds int, onClose, onSnooze func()) *AlertWindow {
+func NewAlertWindow(app fyne.App, event models.Event, snoozeMinutes int, holdTimeSeconds int, openMeeting func(models.Event, models.Conference), onClose, onSnooze func()) *AlertWindow {
 	aw := &AlertWindow{
 		app:             app,
 		event:           event,
 		snoozeMinutes:   snoozeMinutes,
 		holdTimeSeconds: holdTimeSeconds,
+		openMeeting:     openMeeting,
 		onClose:         onClose,
 		onSnooze:        onSnooze,
 		stopMonitoring:  make(chan struct{}),
 	}
+	aw.open("Meeting Alert")
+
+	return aw
+}
 
+// open plays the alarm and creates the full-screen window
+func (aw *AlertWindow) open(windowTitle string) {
 	// Play alarm sound
 	aw.audioPlayer = audio.PlayAlarmSound(resourceAlarmWav.Content())
 
 	// Create window and build UI on the main Fyne thread
 	fyne.Do(func() {
-		aw.window = app.NewWindow("Meeting Alert")
+		aw.window = aw.app.NewWindow(windowTitle)
 		aw.window.SetFullScreen(true)
-		aw.buildUI()
+		if len(aw.missed) > 0 {
+			aw.buildMissedUI()
+		} else {
+			aw.buildUI()
+		}
 
 		// Register Cmd+Q hotkey when window is focused
 		aw.registerCmdQPrevention()
@@ -77,8 +96,6 @@ func NewAlertWindow(app fyne.App, event models.Event, snoozeMinutes int, holdTim
 			}
 		})
 	})
-
-	return aw
 }
 
 func (aw *AlertWindow) buildUI() {
@@ -89,6 +106,12 @@ func (aw *AlertWindow) buildUI() {
 	timeInfo := fmt.Sprintf("Start: %s\nEnd: %s",
 		aw.event.StartTime.Format("3:04 PM"),
 		aw.event.EndTime.Format("3:04 PM"))
+	if original := formatOriginalStart(&aw.event); original != "" {
+		timeInfo += fmt.Sprintf("\n(%s)", original)
+	}
+	if aw.event.AllDay {
+		timeInfo = "All day, " + aw.event.StartTime.Format("Monday, January 2")
+	}
 	timeLabel := widget.NewLabel(timeInfo)
 	timeLabel.Alignment = fyne.TextAlignCenter
 
@@ -103,22 +126,17 @@ func (aw *AlertWindow) buildUI() {
 		description = widget.NewLabel("")
 	}
 
-	var linkButton *widget.Button
-	if aw.event.MeetingLink != "" {
-		linkButton = widget.NewButton("Join Meeting", func() {
-			if u, err := url.Parse(aw.event.MeetingLink); err == nil {
-				fyne.CurrentApp().OpenURL(u)
-			}
-			// Stop audio and close the alert window
-			if aw.audioPlayer != nil {
-				aw.audioPlayer.Stop()
-			}
-			if aw.onClose != nil {
-				aw.onClose()
-			}
-			aw.window.Close()
+	// One join button per meeting link, the best one highlighted
+	conferences := eventConferences(&aw.event)
+	linkButtons := container.NewHBox()
+	for i, conference := range conferences {
+		linkButton := widget.NewButton(joinButtonLabel(conference), func() {
+			aw.joinMeeting(aw.event, conference)
 		})
-		linkButton.Importance = widget.HighImportance
+		if i == 0 {
+			linkButton.Importance = widget.HighImportance
+		}
+		linkButtons.Add(linkButton)
 	}
 
 	var closeButton *components.HoldButton
@@ -135,8 +153,12 @@ func (aw *AlertWindow) buildUI() {
 		container.NewPadded(description),
 	)
 
-	if linkButton != nil {
-		content.Add(container.NewCenter(linkButton))
+	if len(conferences) > 0 {
+		content.Add(container.NewCenter(linkButtons))
+	}
+
+	if dialInfo := aw.buildDialInfo(); dialInfo != nil {
+		content.Add(container.NewCenter(dialInfo))
 	}
 
 	content.Add(widget.NewSeparator())
@@ -165,6 +187,91 @@ func (aw *AlertWindow) buildUI() {
 	aw.window.SetContent(container.NewPadded(centered))
 }
 
+// buildDialInfo shows the dial-ins, meeting ID and passcode with copy buttons, or
+// returns nil if the event has none
+func (aw *AlertWindow) buildDialInfo() fyne.CanvasObject {
+	if len(aw.event.DialIns) == 0 && aw.event.MeetingID == "" && aw.event.Passcode == "" {
+		return nil
+	}
+
+	rows := container.NewVBox()
+
+	for i, dialIn := range aw.event.DialIns {
+		if i == maxDialInRows {
+			rows.Add(widget.NewLabel(fmt.Sprintf("+%d more numbers in the invitation", len(aw.event.DialIns)-maxDialInRows)))
+			break
+		}
+
+		var number fyne.CanvasObject = widget.NewLabel(dialIn.Number)
+		if u, err := url.Parse("tel:" + strings.ReplaceAll(dialIn.Number, "#", "%23")); err == nil {
+			number = widget.NewHyperlink(dialIn.Number, u)
+		}
+		row := container.NewHBox(number)
+		if dialIn.Label != "" {
+			row.Add(widget.NewLabel(dialIn.Label))
+		}
+		row.Add(copyButton(dialIn.Number))
+		rows.Add(row)
+	}
+
+	if aw.event.MeetingID != "" {
+		rows.Add(container.NewHBox(
+			widget.NewLabel("Meeting ID: "+aw.event.MeetingID),
+			copyButton(strings.ReplaceAll(aw.event.MeetingID, " ", "")),
+		))
+	}
+	if aw.event.Passcode != "" {
+		rows.Add(container.NewHBox(
+			widget.NewLabel("Passcode: "+aw.event.Passcode),
+			copyButton(aw.event.Passcode),
+		))
+	}
+
+	return rows
+}
+
+// eventConferences returns the meeting links of an event, falling back to its MeetingLink
+func eventConferences(event *models.Event) []models.Conference {
+	if len(event.Conferences) == 0 && event.MeetingLink != "" {
+		return []models.Conference{{URL: event.MeetingLink}}
+	}
+	return event.Conferences
+}
+
+// joinButtonLabel returns the label of the button that joins conference
+func joinButtonLabel(conference models.Conference) string {
+	if conference.Provider != "" && conference.Provider != "Link" {
+		return "Join " + conference.Provider
+	}
+	return "Join Meeting"
+}
+
+// copyButton returns a small button that puts text on the clipboard
+func copyButton(text string) *widget.Button {
+	button := widget.NewButtonWithIcon("", theme.ContentCopyIcon(), func() {
+		fyne.CurrentApp().Clipboard().SetContent(text)
+	})
+	button.Importance = widget.LowImportance
+	return button
+}
+
+// joinMeeting opens a meeting link of event, stops the alert sound and closes the alert window
+func (aw *AlertWindow) joinMeeting(event models.Event, conference models.Conference) {
+	if aw.openMeeting != nil {
+		aw.openMeeting(event, conference)
+	} else if u, err := url.Parse(conference.URL); err == nil {
+		fyne.CurrentApp().OpenURL(u)
+	}
+	// Stop audio and close the alert window
+	if aw.audioPlayer != nil {
+		aw.audioPlayer.Stop()
+	}
+	if aw.onClose != nil {
+		aw.onClose()
+	}
+	aw.window.Close()
+}
+
 func (aw *AlertWindow) startCloseProgress(button *components.HoldButton) {
 	if aw.closeHeld {
 		return
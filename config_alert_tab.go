@@ -14,6 +14,24 @@ import (
 	"github.com/borgmon/focus-breaker/pkg/models"
 )
 
+// Labels for the participation checks, one per response to an invitation
+const (
+	participationAcceptedLabel    = "Accepted"
+	participationTentativeLabel   = "Tentative"
+	participationNeedsActionLabel = "Not responded"
+	participationDeclinedLabel    = "Declined"
+)
+
+// Labels for the reminder source selector
+const (
+	alarmSourceBothLabel     = "App and calendar reminders"
+	alarmSourceAppLabel      = "App alert times only"
+	alarmSourceCalendarLabel = "Calendar reminders only"
+)
+
+// missedGraceOffLabel is the missed alert option that drops missed alerts
+const missedGraceOffLabel = "Off"
+
 func (cw *ConfigWindow) buildAlertTab() fyne.CanvasObject {
 	// Create Snooze Duration select with 1-min increments (1-15)
 	snoozeOptions := []string{"0 min (disabled)", "1 min", "2 min", "3 min", "4 min", "5 min", "6 min", "7 min", "8 min", "9 min", "10 min", "11 min", "12 min", "13 min", "14 min", "15 min"}
@@ -27,10 +45,42 @@ func (cw *ConfigWindow) buildAlertTab() fyne.CanvasObject {
 		cw.snoozeTimeSelect.SetSelected(strconv.Itoa(currentSnooze) + " min")
 	}
 
-	cw.notifyUnacceptedCheck = widget.NewCheck("Notify for Unaccepted Events", func(checked bool) {
+	// How late an alert missed during sleep is still shown
+	missedGraceOptions := []string{missedGraceOffLabel, "5 min", "10 min", "15 min", "30 min", "60 min"}
+	cw.missedGraceSelect = widget.NewSelect(missedGraceOptions, func(value string) {
 		cw.markChanged()
 	})
-	cw.notifyUnacceptedCheck.SetChecked(cw.config.NotifyUnaccepted)
+	if currentGrace := cw.config.MissedAlertGrace; currentGrace <= 0 {
+		cw.missedGraceSelect.SetSelected(missedGraceOffLabel)
+	} else {
+		cw.missedGraceSelect.SetSelected(strconv.Itoa(currentGrace) + " min")
+	}
+
+	// Which of my responses to an invitation still get alerts
+	cw.participationChecks = widget.NewCheckGroup([]string{
+		participationAcceptedLabel,
+		participationTentativeLabel,
+		participationNeedsActionLabel,
+		participationDeclinedLabel,
+	}, nil)
+	cw.participationChecks.Horizontal = true
+	selectedResponses := []string{}
+	if cw.config.Participation.Accepted {
+		selectedResponses = append(selectedResponses, participationAcceptedLabel)
+	}
+	if cw.config.Participation.Tentative {
+		selectedResponses = append(selectedResponses, participationTentativeLabel)
+	}
+	if cw.config.Participation.NeedsAction {
+		selectedResponses = append(selectedResponses, participationNeedsActionLabel)
+	}
+	if cw.config.Participation.Declined {
+		selectedResponses = append(selectedResponses, participationDeclinedLabel)
+	}
+	cw.participationChecks.SetSelected(selectedResponses)
+	cw.participationChecks.OnChanged = func(selected []string) {
+		cw.markChanged()
+	}
 
 	// Create Hold Time select (1-10 seconds)
 	holdTimeOptions := []string{"1 sec", "2 sec", "3 sec", "4 sec", "5 sec", "6 sec", "7 sec", "8 sec", "9 sec", "10 sec"}
@@ -57,6 +107,14 @@ func (cw *ConfigWindow) buildAlertTab() fyne.CanvasObject {
 		}
 	}
 
+	// Choose whether reminders set in the calendar (VALARM) schedule alerts too
+	cw.alarmSourceSelect = widget.NewSelect(
+		[]string{alarmSourceBothLabel, alarmSourceAppLabel, alarmSourceCalendarLabel},
+		func(value string) {
+			cw.markChanged()
+		})
+	cw.alarmSourceSelect.SetSelected(alarmSourceToLabel(cw.config.AlarmSource))
+
 	// Initialize quiet time data from config
 	cw.quietTimeData = make([]models.TimeRange, len(cw.config.QuietTimeRanges))
 	copy(cw.quietTimeData, cw.config.QuietTimeRanges)
@@ -171,12 +229,22 @@ func (cw *ConfigWindow) buildAlertTab() fyne.CanvasObject {
 	alertBeforeHelp.Wrapping = fyne.TextWrapWord
 	alertBeforeHelp.Importance = widget.MediumImportance
 
+	alarmSourceLabel := widget.NewLabel("Reminders From:")
+	alarmSourceHelp := widget.NewLabel("Also alert at reminders set on the event in your calendar, or use only those")
+	alarmSourceHelp.Wrapping = fyne.TextWrapWord
+	alarmSourceHelp.Importance = widget.MediumImportance
+
 	snoozeLabel := widget.NewLabel("Snooze Duration:")
 	snoozeHelp := widget.NewLabel("Set to 0 to disable snooze functionality")
 	snoozeHelp.Importance = widget.MediumImportance
 
-	notifyLabel := widget.NewLabel("Notify Unaccepted:")
-	notifyHelp := widget.NewLabel("If unchecked, only shows alerts for events you've accepted")
+	missedGraceLabel := widget.NewLabel("Catch Up Missed:")
+	missedGraceHelp := widget.NewLabel("Alerts missed while the computer was asleep are shown together on wake-up if they are at most this late")
+	missedGraceHelp.Wrapping = fyne.TextWrapWord
+	missedGraceHelp.Importance = widget.MediumImportance
+
+	notifyLabel := widget.NewLabel("Alert When I Responded:")
+	notifyHelp := widget.NewLabel("Alerts only for events with these responses. Add your email addresses to each calendar source so your response can be found.")
 	notifyHelp.Wrapping = fyne.TextWrapWord
 	notifyHelp.Importance = widget.MediumImportance
 
@@ -298,7 +366,7 @@ func (cw *ConfigWindow) buildAlertTab() fyne.CanvasObject {
 
 	// Wrap snooze select and checkbox to control their height
 	snoozeContainer := container.NewVBox(cw.snoozeTimeSelect)
-	notifyContainer := container.NewVBox(cw.notifyUnacceptedCheck)
+	notifyContainer := container.NewVBox(cw.participationChecks)
 	holdTimeContainer := container.NewVBox(cw.holdTimeSelect)
 
 	// Use FormLayout for proper label-value alignment
@@ -306,9 +374,15 @@ func (cw *ConfigWindow) buildAlertTab() fyne.CanvasObject {
 		container.NewVBox(alertBeforeLabel, alertBeforeHelp),
 		alertBeforeContainer,
 
+		container.NewVBox(alarmSourceLabel, alarmSourceHelp),
+		container.NewVBox(cw.alarmSourceSelect),
+
 		container.NewVBox(snoozeLabel, snoozeHelp),
 		snoozeContainer,
 
+		container.NewVBox(missedGraceLabel, missedGraceHelp),
+		container.NewVBox(cw.missedGraceSelect),
+
 		container.NewVBox(notifyLabel, notifyHelp),
 		notifyContainer,
 
@@ -327,3 +401,45 @@ func (cw *ConfigWindow) buildAlertTab() fyne.CanvasObject {
 
 	return container.NewPadded(container.NewVScroll(content))
 }
+
+// alarmSourceToLabel returns the selector label for an alarm source
+func alarmSourceToLabel(source models.AlarmSource) string {
+	switch source {
+	case models.AlarmSourceApp:
+		return alarmSourceAppLabel
+	case models.AlarmSourceCalendar:
+		return alarmSourceCalendarLabel
+	default:
+		return alarmSourceBothLabel
+	}
+}
+
+// alarmSourceFromLabel returns the alarm source for a selector label
+func alarmSourceFromLabel(label string) models.AlarmSource {
+	switch label {
+	case alarmSourceAppLabel:
+		return models.AlarmSourceApp
+	case alarmSourceCalendarLabel:
+		return models.AlarmSourceCalendar
+	default:
+		return models.AlarmSourceBoth
+	}
+}
+
+// getParticipationFromUI returns the participation policy selected in the alert tab
+func (cw *ConfigWindow) getParticipationFromUI() models.ParticipationPolicy {
+	policy := models.ParticipationPolicy{}
+	for _, selected := range cw.participationChecks.Selected {
+		switch selected {
+		case participationAcceptedLabel:
+			policy.Accepted = true
+		case participationTentativeLabel:
+			policy.Tentative = true
+		case participationNeedsActionLabel:
+			policy.NeedsAction = true
+		case participationDeclinedLabel:
+			policy.Declined = true
+		}
+	}
+	return policy
+}
@@ -1,8 +1,16 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -11,10 +19,23 @@ import (
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"github.com/borgmon/focus-breaker/pkg/calendar"
 	"github.com/borgmon/focus-breaker/pkg/models"
 	"github.com/google/uuid"
 )
 
+// discoveryTimeout bounds CalDAV discovery started from the add-source dialog
+const discoveryTimeout = 30 * time.Second
+
+// staleWarningOffLabel is the stale warning option that disables the warning
+const staleWarningOffLabel = "Never"
+
+// Labels for the source type selector in the add-source dialog
+const (
+	sourceTypeICalLabel   = "iCal URL"
+	sourceTypeCalDAVLabel = "CalDAV"
+)
+
 func (cw *ConfigWindow) buildCalendarTab() fyne.CanvasObject {
 	// Initialize iCal sources data from config
 	cw.icalSourcesData = []models.ICalSource{}
@@ -35,12 +56,15 @@ func (cw *ConfigWindow) buildCalendarTab() fyne.CanvasObject {
 			nameLabel.TextStyle.Bold = true
 			urlLabel := widget.NewLabel("URL")
 			urlLabel.Importance = widget.MediumImportance
-			return container.NewVBox(nameLabel, urlLabel)
+			healthLabel := widget.NewLabel("Health")
+			healthLabel.Truncation = fyne.TextTruncateEllipsis
+			return container.NewVBox(nameLabel, urlLabel, healthLabel)
 		},
 		func(i widget.ListItemID, o fyne.CanvasObject) {
 			vbox := o.(*fyne.Container)
 			nameLabel := vbox.Objects[0].(*widget.Label)
 			urlLabel := vbox.Objects[1].(*widget.Label)
+			healthLabel := vbox.Objects[2].(*widget.Label)
 
 			source := cw.icalSourcesData[i]
 			nameLabel.SetText(source.Name)
@@ -50,7 +74,32 @@ func (cw *ConfigWindow) buildCalendarTab() fyne.CanvasObject {
 			if len(displayURL) > 60 {
 				displayURL = displayURL[:57] + "..."
 			}
+			if source.IsCalDAV() {
+				displayURL = fmt.Sprintf("CalDAV (%d calendar(s)) - %s", len(source.CalendarURLs), displayURL)
+			}
 			urlLabel.SetText(displayURL)
+
+			// Show sync health of the source
+			health := cw.healthStore.Get(source.ID)
+			healthLabel.SetText(formatSourceHealth(health))
+			staleReason := ""
+			if health != nil {
+				staleReason = health.StaleReason(cw.clock.Now(), cw.config.GetStaleWarningThreshold())
+			}
+			switch {
+			case health == nil:
+				healthLabel.Importance = widget.LowImportance
+			case staleReason != "":
+				healthLabel.SetText("Stale - " + staleReason + " - " + formatSourceHealth(health))
+				healthLabel.Importance = widget.DangerImportance
+			case health.IsHealthy():
+				healthLabel.Importance = widget.SuccessImportance
+			case health.UsingCache:
+				healthLabel.Importance = widget.WarningImportance
+			default:
+				healthLabel.Importance = widget.DangerImportance
+			}
+			healthLabel.Refresh()
 		})
 
 	cw.icalSourcesList.OnSelected = func(id widget.ListItemID) {
@@ -59,75 +108,17 @@ func (cw *ConfigWindow) buildCalendarTab() fyne.CanvasObject {
 
 	// Plus button to add new iCal source
 	plusButton := widget.NewButton("", func() {
-		nameEntry := widget.NewEntry()
-		nameEntry.SetPlaceHolder("e.g., Work Calendar")
-		nameEntry.Validator = func(s string) error {
-			if s == "" {
-				return fmt.Errorf("name is required")
-			}
-			return nil
-		}
-
-		urlEntry := widget.NewMultiLineEntry()
-		urlEntry.SetPlaceHolder("https://calendar.example.com/ical/...")
-		urlEntry.Wrapping = fyne.TextWrapBreak
-		urlEntry.SetMinRowsVisible(5)
-		urlEntry.Validator = func(s string) error {
-			if s == "" {
-				return fmt.Errorf("URL is required")
-			}
-
-			// Basic URL validation - check if it starts with http:// or https://
-			if len(s) < 10 {
-				return fmt.Errorf("please enter a valid URL (http:// or https://)")
-			}
-			hasValidPrefix := false
-			if len(s) >= 7 && s[:7] == "http://" {
-				hasValidPrefix = true
-			}
-			if len(s) >= 8 && s[:8] == "https://" {
-				hasValidPrefix = true
-			}
-			if !hasValidPrefix {
-				return fmt.Errorf("URL must start with http:// or https://")
-			}
-
-			// Check for duplicate URLs
-			for _, existing := range cw.icalSourcesData {
-				if existing.URL == s {
-					return fmt.Errorf("this calendar URL has already been added")
-				}
-			}
-
-			return nil
-		}
+		cw.showSourceDialog(-1)
+	})
+	plusButton.Icon = theme.ContentAddIcon()
 
-		formItems := []*widget.FormItem{
-			widget.NewFormItem("Name", nameEntry),
-			widget.NewFormItem("URL", urlEntry),
+	// Edit button to change the selected iCal source
+	editButton := widget.NewButton("", func() {
+		if selectedIndex >= 0 && selectedIndex < len(cw.icalSourcesData) {
+			cw.showSourceDialog(selectedIndex)
 		}
-
-		addDialog := dialog.NewForm("Add iCal Source", "Add", "Cancel", formItems, func(confirmed bool) {
-			if !confirmed {
-				return
-			}
-
-			// Add the new source with generated UUID
-			cw.icalSourcesData = append(cw.icalSourcesData, models.ICalSource{
-				ID:   uuid.New().String(),
-				Name: nameEntry.Text,
-				URL:  urlEntry.Text,
-			})
-
-			cw.icalSourcesList.Refresh()
-			cw.markChanged()
-		}, cw.window)
-
-		// Resize the dialog to be larger
-		addDialog.Resize(fyne.NewSize(600, 300))
-		addDialog.Show()
 	})
-	plusButton.Icon = theme.ContentAddIcon()
+	editButton.Icon = theme.DocumentCreateIcon()
 
 	// Minus button to remove selected iCal source
 	minusButton := widget.NewButton("", func() {
@@ -149,7 +140,7 @@ func (cw *ConfigWindow) buildCalendarTab() fyne.CanvasObject {
 	})
 	minusButton.Icon = theme.ContentRemoveIcon()
 
-	addControls := container.NewHBox(plusButton, minusButton)
+	addControls := container.NewHBox(plusButton, minusButton, editButton)
 
 	// Wrap list in a scroll container with minimum height
 	listScroll := container.NewScroll(cw.icalSourcesList)
@@ -176,6 +167,63 @@ func (cw *ConfigWindow) buildCalendarTab() fyne.CanvasObject {
 	currentInterval := cw.config.UpdateInterval
 	cw.updateIntervalSelect.SetSelected(strconv.Itoa(currentInterval) + " min")
 
+	// How many days ahead events are fetched
+	lookaheadOptions := []string{"1 day", "2 days", "3 days", "4 days", "7 days", "14 days"}
+	cw.lookaheadSelect = widget.NewSelect(lookaheadOptions, func(value string) {
+		cw.markChanged()
+	})
+	switch currentLookahead := cw.config.LookaheadDays; {
+	case currentLookahead <= 1:
+		cw.lookaheadSelect.SetSelected("1 day")
+	default:
+		cw.lookaheadSelect.SetSelected(strconv.Itoa(currentLookahead) + " days")
+	}
+
+	// Per-source fetch timeout
+	timeoutOptions := []string{"10 sec", "20 sec", "30 sec", "60 sec", "90 sec", "120 sec"}
+	cw.sourceTimeoutSelect = widget.NewSelect(timeoutOptions, func(value string) {
+		cw.markChanged()
+	})
+	currentTimeout := cw.config.SourceTimeout
+	if currentTimeout <= 0 {
+		currentTimeout = 30
+	}
+	cw.sourceTimeoutSelect.SetSelected(strconv.Itoa(currentTimeout) + " sec")
+
+	// How long a source may fail before Focus Breaker warns about it
+	staleOptions := []string{"1 hour", "3 hours", "6 hours", "12 hours", "24 hours", staleWarningOffLabel}
+	cw.staleWarningSelect = widget.NewSelect(staleOptions, func(value string) {
+		cw.markChanged()
+	})
+	switch currentStale := cw.config.StaleWarningHours; {
+	case currentStale <= 0:
+		cw.staleWarningSelect.SetSelected(staleWarningOffLabel)
+	case currentStale == 1:
+		cw.staleWarningSelect.SetSelected("1 hour")
+	default:
+		cw.staleWarningSelect.SetSelected(strconv.Itoa(currentStale) + " hours")
+	}
+
+	// Custom meeting providers, one "Name = regex" per line
+	cw.meetingPatternsEntry = widget.NewMultiLineEntry()
+	cw.meetingPatternsEntry.SetPlaceHolder("Company Video = https://video\\.example\\.com/room/\\S+")
+	cw.meetingPatternsEntry.SetMinRowsVisible(3)
+	cw.meetingPatternsEntry.SetText(formatMeetingProviderLines(cw.config.MeetingProviders))
+	cw.meetingPatternsEntry.Validator = func(s string) error {
+		_, err := parseMeetingProviderLines(s)
+		return err
+	}
+	cw.meetingPatternsEntry.OnChanged = func(string) {
+		cw.markChanged()
+	}
+
+	// Providers whose join links skip the browser and open the desktop app
+	cw.nativeAppChecks = widget.NewCheckGroup(calendar.NativeAppProviders(), func([]string) {
+		cw.markChanged()
+	})
+	cw.nativeAppChecks.Horizontal = true
+	cw.nativeAppChecks.SetSelected(cw.config.NativeApps)
+
 	syncStatusLabel := widget.NewLabel("")
 	syncStatusLabel.Importance = widget.MediumImportance
 
@@ -194,7 +242,8 @@ func (cw *ConfigWindow) buildCalendarTab() fyne.CanvasObject {
 					syncStatusLabel.Importance = widget.SuccessImportance
 					syncStatusLabel.Refresh()
 					cw.syncNowButton.Enable()
-					// Refresh schedules tab after sync
+					// Refresh source health and schedules tab after sync
+					cw.icalSourcesList.Refresh()
 					cw.refreshSchedulesData()
 
 					// Clear sync message after 3 seconds
@@ -215,7 +264,7 @@ func (cw *ConfigWindow) buildCalendarTab() fyne.CanvasObject {
 
 	// Create labels with help text
 	icalSourcesLabel := widget.NewLabel("iCal Sources:")
-	icalSourcesHelp := widget.NewLabel("Add one or more named calendar sources. Events from all calendars will be synced.")
+	icalSourcesHelp := widget.NewLabel("Add one or more named iCal feeds, CalDAV servers, or local .ics files (file://). Events from all calendars will be synced.")
 	icalSourcesHelp.Wrapping = fyne.TextWrapWord
 	icalSourcesHelp.Importance = widget.MediumImportance
 
@@ -223,6 +272,31 @@ func (cw *ConfigWindow) buildCalendarTab() fyne.CanvasObject {
 	updateIntervalHelp := widget.NewLabel("How often to sync calendar events from all iCal sources")
 	updateIntervalHelp.Importance = widget.MediumImportance
 
+	lookaheadLabel := widget.NewLabel("Look Ahead:")
+	lookaheadHelp := widget.NewLabel("How far ahead to load events, e.g. 3 days to see Monday's meetings on a Friday")
+	lookaheadHelp.Wrapping = fyne.TextWrapWord
+	lookaheadHelp.Importance = widget.MediumImportance
+
+	sourceTimeoutLabel := widget.NewLabel("Source Timeout:")
+	sourceTimeoutHelp := widget.NewLabel("Give up on a calendar source that takes longer than this to respond")
+	sourceTimeoutHelp.Wrapping = fyne.TextWrapWord
+	sourceTimeoutHelp.Importance = widget.MediumImportance
+
+	staleWarningLabel := widget.NewLabel("Warn When Stale:")
+	staleWarningHelp := widget.NewLabel("Warn when a calendar has failed to sync for this long, or suddenly comes back empty")
+	staleWarningHelp.Wrapping = fyne.TextWrapWord
+	staleWarningHelp.Importance = widget.MediumImportance
+
+	meetingPatternsLabel := widget.NewLabel("Meeting Links:")
+	meetingPatternsHelp := widget.NewLabel("Zoom, Meet, Teams, Webex, Jitsi, Whereby, Chime and Slack huddles are built in. Add other providers as \"Name = regex\", one per line.")
+	meetingPatternsHelp.Wrapping = fyne.TextWrapWord
+	meetingPatternsHelp.Importance = widget.MediumImportance
+
+	nativeAppsLabel := widget.NewLabel("Open In App:")
+	nativeAppsHelp := widget.NewLabel("Join these meetings in the desktop app instead of the browser")
+	nativeAppsHelp.Wrapping = fyne.TextWrapWord
+	nativeAppsHelp.Importance = widget.MediumImportance
+
 	syncLabel := widget.NewLabel("Sync Calendars:")
 	syncHelp := widget.NewLabel("Manually sync all calendar sources to fetch the latest events")
 	syncHelp.Importance = widget.MediumImportance
@@ -243,6 +317,21 @@ func (cw *ConfigWindow) buildCalendarTab() fyne.CanvasObject {
 		container.NewVBox(updateIntervalLabel, updateIntervalHelp),
 		updateIntervalContainer,
 
+		container.NewVBox(lookaheadLabel, lookaheadHelp),
+		container.NewVBox(cw.lookaheadSelect),
+
+		container.NewVBox(sourceTimeoutLabel, sourceTimeoutHelp),
+		container.NewVBox(cw.sourceTimeoutSelect),
+
+		container.NewVBox(staleWarningLabel, staleWarningHelp),
+		container.NewVBox(cw.staleWarningSelect),
+
+		container.NewVBox(meetingPatternsLabel, meetingPatternsHelp),
+		cw.meetingPatternsEntry,
+
+		container.NewVBox(nativeAppsLabel, nativeAppsHelp),
+		cw.nativeAppChecks,
+
 		container.NewVBox(syncLabel, syncHelp),
 		syncButtonContainer,
 	)
@@ -255,3 +344,555 @@ func (cw *ConfigWindow) buildCalendarTab() fyne.CanvasObject {
 
 	return container.NewPadded(container.NewVScroll(content))
 }
+
+// showSourceDialog shows the form for adding an iCal feed or CalDAV server, or for
+// editing the source at index (-1 adds a new one)
+func (cw *ConfigWindow) showSourceDialog(index int) {
+	editing := index >= 0 && index < len(cw.icalSourcesData)
+	existing := models.ICalSource{}
+	if editing {
+		existing = cw.icalSourcesData[index]
+	}
+
+	// isDuplicateURL reports whether another source already uses url
+	isDuplicateURL := func(url string) bool {
+		for i, other := range cw.icalSourcesData {
+			if other.URL == url && !(editing && i == index) {
+				return true
+			}
+		}
+		return false
+	}
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("e.g., Work Calendar")
+	nameEntry.Validator = func(s string) error {
+		if s == "" {
+			return fmt.Errorf("name is required")
+		}
+		return nil
+	}
+
+	typeSelect := widget.NewSelect([]string{sourceTypeICalLabel, sourceTypeCalDAVLabel}, nil)
+	typeSelect.SetSelected(sourceTypeICalLabel)
+
+	urlEntry := widget.NewMultiLineEntry()
+	urlEntry.SetPlaceHolder("https://calendar.example.com/ical/...")
+	urlEntry.Wrapping = fyne.TextWrapBreak
+	urlEntry.SetMinRowsVisible(5)
+	urlEntry.Validator = func(s string) error {
+		if s == "" {
+			return fmt.Errorf("URL is required")
+		}
+
+		// Local .ics file or directory
+		if strings.HasPrefix(strings.ToLower(s), "file://") {
+			path, err := calendar.LocalPath(s)
+			if err != nil {
+				return err
+			}
+			if _, err := os.Stat(path); err != nil {
+				return fmt.Errorf("file or directory not found: %s", path)
+			}
+			if isDuplicateURL(s) {
+				return fmt.Errorf("this calendar URL has already been added")
+			}
+			return nil
+		}
+
+		// Basic URL validation - check if it starts with http:// or https://
+		if len(s) < 10 {
+			return fmt.Errorf("please enter a valid URL (http:// or https://)")
+		}
+		hasValidPrefix := false
+		if len(s) >= 7 && s[:7] == "http://" {
+			hasValidPrefix = true
+		}
+		if len(s) >= 8 && s[:8] == "https://" {
+			hasValidPrefix = true
+		}
+		if !hasValidPrefix {
+			return fmt.Errorf("URL must start with http://, https:// or file://")
+		}
+
+		// Check for duplicate URLs
+		if isDuplicateURL(s) {
+			return fmt.Errorf("this calendar URL has already been added")
+		}
+
+		return nil
+	}
+
+	usernameEntry := widget.NewEntry()
+	usernameEntry.SetPlaceHolder("Optional")
+	passwordEntry := widget.NewPasswordEntry()
+	passwordEntry.SetPlaceHolder("Optional")
+
+	tokenEntry := widget.NewPasswordEntry()
+	tokenEntry.SetPlaceHolder("Optional")
+
+	headersEntry := widget.NewMultiLineEntry()
+	headersEntry.SetPlaceHolder("X-Api-Key: abc123")
+	headersEntry.SetMinRowsVisible(3)
+	headersEntry.Validator = func(s string) error {
+		_, err := parseHeaderLines(s)
+		return err
+	}
+
+	myEmailsEntry := widget.NewEntry()
+	myEmailsEntry.SetPlaceHolder("me@example.com, me@personal.example")
+
+	// Filter rules
+	includeTitleEntry := widget.NewEntry()
+	includeTitleEntry.SetPlaceHolder("(?i)standup|planning")
+	includeTitleEntry.Validator = func(s string) error {
+		return calendar.ValidateFilterRules(models.FilterRules{IncludeTitle: s})
+	}
+	excludeTitleEntry := widget.NewEntry()
+	excludeTitleEntry.SetPlaceHolder("(?i)^(lunch|focus time)")
+	excludeTitleEntry.Validator = func(s string) error {
+		return calendar.ValidateFilterRules(models.FilterRules{ExcludeTitle: s})
+	}
+	organizersEntry := widget.NewEntry()
+	organizersEntry.SetPlaceHolder("boss@example.com, @example.com")
+	categoriesEntry := widget.NewEntry()
+	categoriesEntry.SetPlaceHolder("Meeting, Interview")
+	skipFreeCheck := widget.NewCheck("Skip events marked as free", nil)
+	maxAttendeesEntry := widget.NewEntry()
+	maxAttendeesEntry.SetPlaceHolder("No limit")
+	maxAttendeesEntry.Validator = func(s string) error {
+		if strings.TrimSpace(s) == "" {
+			return nil
+		}
+		if n, err := strconv.Atoi(strings.TrimSpace(s)); err != nil || n < 0 {
+			return fmt.Errorf("enter a number of attendees")
+		}
+		return nil
+	}
+
+	allDayEntry := widget.NewEntry()
+	allDayEntry.SetPlaceHolder("09:00")
+	allDayEntry.Validator = func(s string) error {
+		return calendar.ValidateAllDayAlertTime(s)
+	}
+
+	timezoneEntry := widget.NewEntry()
+	timezoneEntry.SetPlaceHolder("Europe/Berlin")
+	timezoneEntry.Validator = func(s string) error {
+		if strings.TrimSpace(s) == "" {
+			return nil
+		}
+		return calendar.ValidateTimezone(strings.TrimSpace(s))
+	}
+
+	openCommandEntry := widget.NewEntry()
+	openCommandEntry.SetPlaceHolder(`google-chrome --profile-directory="Profile 1" {url}`)
+	openCommandEntry.Validator = func(s string) error {
+		_, err := splitCommandLine(s)
+		return err
+	}
+
+	// CalDAV calendar picker, filled in by discovery. Calendars are told apart by URL,
+	// two accounts may both have one called "Calendar".
+	discoveredCalendars := []calendar.CalDAVCalendar{}
+	calendarLabels := []string{}
+	calendarChecks := widget.NewCheckGroup([]string{}, nil)
+	discoverStatus := widget.NewLabel("")
+	discoverStatus.Importance = widget.MediumImportance
+	discoverStatus.Wrapping = fyne.TextWrapWord
+
+	selectedCalendarURLs := func() []string {
+		urls := []string{}
+		for i, cal := range discoveredCalendars {
+			if slices.Contains(calendarChecks.Selected, calendarLabels[i]) {
+				urls = append(urls, cal.URL)
+			}
+		}
+		return urls
+	}
+	showCalendars := func(calendars []calendar.CalDAVCalendar, selectedURLs []string) {
+		discoveredCalendars = calendars
+		calendarLabels = calendarPickerLabels(calendars)
+		selected := []string{}
+		for i, cal := range calendars {
+			if slices.Contains(selectedURLs, cal.URL) {
+				selected = append(selected, calendarLabels[i])
+			}
+		}
+		calendarChecks.Options = calendarLabels
+		calendarChecks.SetSelected(selected)
+		calendarChecks.Refresh()
+	}
+
+	var discoverButton *widget.Button
+	discoverButton = widget.NewButton("Discover Calendars", func() {
+		if err := urlEntry.Validate(); err != nil {
+			discoverStatus.SetText(err.Error())
+			return
+		}
+
+		discoverButton.Disable()
+		discoverStatus.SetText("Discovering calendars...")
+		source := models.ICalSource{
+			Name:        nameEntry.Text,
+			URL:         urlEntry.Text,
+			Type:        models.SourceTypeCalDAV,
+			Username:    usernameEntry.Text,
+			Password:    passwordEntry.Text,
+			BearerToken: tokenEntry.Text,
+		}
+		source.Headers, _ = parseHeaderLines(headersEntry.Text)
+
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), discoveryTimeout)
+			defer cancel()
+
+			calendars, err := calendar.DiscoverCalDAVCalendars(ctx, source)
+			fyne.Do(func() {
+				discoverButton.Enable()
+				if err != nil {
+					discoverStatus.SetText("Discovery failed: " + err.Error())
+					return
+				}
+
+				// Keep the picked calendars, or pick all on first discovery
+				selectedURLs := selectedCalendarURLs()
+				if len(discoveredCalendars) == 0 {
+					for _, cal := range calendars {
+						selectedURLs = append(selectedURLs, cal.URL)
+					}
+				}
+				showCalendars(calendars, selectedURLs)
+				discoverStatus.SetText(fmt.Sprintf("Found %d calendar(s)", len(calendars)))
+			})
+		}()
+	})
+	discoverButton.Icon = theme.SearchIcon()
+
+	calendarPicker := container.NewVBox(discoverButton, discoverStatus, calendarChecks)
+
+	typeSelect.OnChanged = func(value string) {
+		if value == sourceTypeCalDAVLabel {
+			urlEntry.SetPlaceHolder("https://cloud.example.com/remote.php/dav")
+			calendarPicker.Show()
+		} else {
+			urlEntry.SetPlaceHolder("https://calendar.example.com/ical/...")
+			calendarPicker.Hide()
+		}
+	}
+	calendarPicker.Hide()
+
+	// Pre-fill the form when editing
+	if editing {
+		nameEntry.SetText(existing.Name)
+		urlEntry.SetText(existing.URL)
+		usernameEntry.SetText(existing.Username)
+		passwordEntry.SetText(existing.Password)
+		tokenEntry.SetText(existing.BearerToken)
+		headersEntry.SetText(formatHeaderLines(existing.Headers))
+		myEmailsEntry.SetText(strings.Join(existing.MyEmails, ", "))
+		openCommandEntry.SetText(existing.OpenCommand)
+		timezoneEntry.SetText(existing.Timezone)
+		includeTitleEntry.SetText(existing.Filters.IncludeTitle)
+		excludeTitleEntry.SetText(existing.Filters.ExcludeTitle)
+		organizersEntry.SetText(strings.Join(existing.Filters.Organizers, ", "))
+		categoriesEntry.SetText(strings.Join(existing.Filters.Categories, ", "))
+		skipFreeCheck.SetChecked(existing.Filters.SkipFree)
+		allDayEntry.SetText(existing.AllDayAlertTime)
+		if existing.Filters.MaxAttendees > 0 {
+			maxAttendeesEntry.SetText(strconv.Itoa(existing.Filters.MaxAttendees))
+		}
+		if existing.IsCalDAV() {
+			typeSelect.SetSelected(sourceTypeCalDAVLabel)
+
+			// List the selected calendars by URL until discovery has fetched their names
+			calendars := []calendar.CalDAVCalendar{}
+			for _, calendarURL := range existing.CalendarURLs {
+				calendars = append(calendars, calendar.CalDAVCalendar{URL: calendarURL, Name: calendarNameFromURL(calendarURL)})
+			}
+			showCalendars(calendars, existing.CalendarURLs)
+			if len(calendars) > 0 {
+				discoverButton.OnTapped()
+			}
+		}
+	}
+
+	urlItem := widget.NewFormItem("URL", urlEntry)
+	urlItem.HintText = "Use file:///path/to/calendar.ics or a directory of .ics files for local calendars"
+	usernameItem := widget.NewFormItem("Username", usernameEntry)
+	usernameItem.HintText = "Sent as HTTP Basic auth on every fetch"
+	tokenItem := widget.NewFormItem("Bearer Token", tokenEntry)
+	tokenItem.HintText = "Used instead of username/password when set"
+	headersItem := widget.NewFormItem("Headers", headersEntry)
+	headersItem.HintText = "One \"Name: value\" per line"
+	calendarsItem := widget.NewFormItem("Calendars", calendarPicker)
+	calendarsItem.HintText = "CalDAV only - leave empty to use the URL as the calendar"
+	myEmailsItem := widget.NewFormItem("My Emails", myEmailsEntry)
+	myEmailsItem.HintText = "Your addresses on this calendar, comma separated - used to find your response to invitations"
+	timezoneItem := widget.NewFormItem("Time Zone", timezoneEntry)
+	timezoneItem.HintText = "Zone of event times written without one (floating times) - leave empty for local time"
+	openCommandItem := widget.NewFormItem("Open Links With", openCommandEntry)
+	openCommandItem.HintText = "Browser or launcher command for this calendar's meeting links, {url} is replaced by the link - leave empty for the default browser"
+	includeTitleItem := widget.NewFormItem("Only Titles", includeTitleEntry)
+	includeTitleItem.HintText = "Regex - only alert events whose title matches"
+	excludeTitleItem := widget.NewFormItem("Skip Titles", excludeTitleEntry)
+	excludeTitleItem.HintText = "Regex - never alert events whose title matches"
+	organizersItem := widget.NewFormItem("Only Organizers", organizersEntry)
+	organizersItem.HintText = "Only alert events organized by these addresses or @domains, comma separated"
+	categoriesItem := widget.NewFormItem("Only Categories", categoriesEntry)
+	categoriesItem.HintText = "Only alert events in one of these categories, comma separated"
+	maxAttendeesItem := widget.NewFormItem("Max Attendees", maxAttendeesEntry)
+	maxAttendeesItem.HintText = "Skip events with more attendees than this, e.g. all-hands"
+	allDayItem := widget.NewFormItem("All-Day Events", allDayEntry)
+	allDayItem.HintText = "Alert all-day events at this time (HH:MM) on their first day - leave empty to skip them"
+
+	formItems := []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("Type", typeSelect),
+		urlItem,
+		usernameItem,
+		widget.NewFormItem("Password", passwordEntry),
+		tokenItem,
+		headersItem,
+		calendarsItem,
+		myEmailsItem,
+		timezoneItem,
+		openCommandItem,
+		includeTitleItem,
+		excludeTitleItem,
+		organizersItem,
+		categoriesItem,
+		widget.NewFormItem("Free Events", skipFreeCheck),
+		maxAttendeesItem,
+		allDayItem,
+	}
+
+	title, confirm := "Add Calendar Source", "Add"
+	if editing {
+		title, confirm = "Edit Calendar Source", "Save"
+	}
+
+	addDialog := dialog.NewForm(title, confirm, "Cancel", formItems, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		headers, _ := parseHeaderLines(headersEntry.Text)
+
+		// New sources get a generated UUID; edited ones keep theirs so caches and
+		// alert statuses carry over
+		sourceID := uuid.New().String()
+		if editing {
+			sourceID = existing.ID
+		}
+
+		source := models.ICalSource{
+			ID:          sourceID,
+			Name:        nameEntry.Text,
+			URL:         urlEntry.Text,
+			Type:        models.SourceTypeICal,
+			Username:    usernameEntry.Text,
+			Password:    passwordEntry.Text,
+			BearerToken: tokenEntry.Text,
+			Headers:     headers,
+			MyEmails:    parseEmailList(myEmailsEntry.Text),
+			OpenCommand: strings.TrimSpace(openCommandEntry.Text),
+			Timezone:    strings.TrimSpace(timezoneEntry.Text),
+
+			AllDayAlertTime: strings.TrimSpace(allDayEntry.Text),
+		}
+
+		source.Filters = models.FilterRules{
+			IncludeTitle: strings.TrimSpace(includeTitleEntry.Text),
+			ExcludeTitle: strings.TrimSpace(excludeTitleEntry.Text),
+			Organizers:   parseEmailList(organizersEntry.Text),
+			Categories:   parseCategoryList(categoriesEntry.Text),
+			SkipFree:     skipFreeCheck.Checked,
+		}
+		source.Filters.MaxAttendees, _ = strconv.Atoi(strings.TrimSpace(maxAttendeesEntry.Text))
+
+		if typeSelect.Selected == sourceTypeCalDAVLabel {
+			source.Type = models.SourceTypeCalDAV
+			source.CalendarURLs = selectedCalendarURLs()
+		}
+
+		if editing {
+			cw.icalSourcesData[index] = source
+		} else {
+			cw.icalSourcesData = append(cw.icalSourcesData, source)
+		}
+
+		cw.icalSourcesList.Refresh()
+		cw.markChanged()
+	}, cw.window)
+
+	// Resize the dialog to be larger
+	addDialog.Resize(fyne.NewSize(640, 760))
+	addDialog.Show()
+}
+
+// parseHeaderLines parses "Name: value" lines into a header map, ignoring blank lines
+func parseHeaderLines(text string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !found || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("invalid header line %q - use \"Name: value\"", line)
+		}
+		headers[name] = strings.TrimSpace(value)
+	}
+
+	if len(headers) == 0 {
+		return nil, nil
+	}
+	return headers, nil
+}
+
+// formatHeaderLines formats a header map as "Name: value" lines, sorted by name
+func formatHeaderLines(headers map[string]string) string {
+	names := []string{}
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := []string{}
+	for _, name := range names {
+		lines = append(lines, name+": "+headers[name])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseEmailList splits a comma or whitespace separated list of email addresses
+func parseEmailList(text string) []string {
+	emails := []string{}
+	for _, email := range strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\n' || r == '\t'
+	}) {
+		emails = append(emails, strings.TrimPrefix(strings.ToLower(email), "mailto:"))
+	}
+
+	if len(emails) == 0 {
+		return nil
+	}
+	return emails
+}
+
+// parseCategoryList parses a comma separated list of categories
+func parseCategoryList(text string) []string {
+	categories := []string{}
+	for _, category := range strings.Split(text, ",") {
+		if category = strings.TrimSpace(category); category != "" {
+			categories = append(categories, category)
+		}
+	}
+
+	if len(categories) == 0 {
+		return nil
+	}
+	return categories
+}
+
+// parseMeetingProviderLines parses "Name = regex" lines into meeting provider patterns
+func parseMeetingProviderLines(text string) ([]models.MeetingProviderPattern, error) {
+	providers := []models.MeetingProviderPattern{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		name, pattern, found := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		pattern = strings.TrimSpace(pattern)
+		if !found || name == "" || pattern == "" {
+			return nil, fmt.Errorf("invalid line %q - use \"Name = regex\"", line)
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid regex for %s: %w", name, err)
+		}
+		providers = append(providers, models.MeetingProviderPattern{Name: name, Pattern: pattern})
+	}
+
+	if len(providers) == 0 {
+		return nil, nil
+	}
+	return providers, nil
+}
+
+// formatMeetingProviderLines formats meeting provider patterns as "Name = regex" lines
+func formatMeetingProviderLines(providers []models.MeetingProviderPattern) string {
+	lines := []string{}
+	for _, provider := range providers {
+		lines = append(lines, provider.Name+" = "+provider.Pattern)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatSourceHealth describes the sync health of a source in one line
+func formatSourceHealth(health *models.SourceHealth) string {
+	if health == nil {
+		return "Not synced yet"
+	}
+
+	if health.IsHealthy() {
+		return fmt.Sprintf("Synced %s - %d event(s)", health.LastSuccess.Format("Mon 3:04 PM"), health.EventCount)
+	}
+
+	lastSuccess := "never"
+	if !health.LastSuccess.IsZero() {
+		lastSuccess = health.LastSuccess.Format("Mon 3:04 PM")
+	}
+
+	status := fmt.Sprintf("Failing (%d in a row, last success %s): %s",
+		health.ConsecutiveFailures, lastSuccess, health.LastError)
+	if health.UsingCache {
+		status = fmt.Sprintf("Using cached copy (%d event(s)) - %s", health.EventCount, status)
+	}
+	return status
+}
+
+// calendarPickerLabels returns a distinct label per calendar for the CalDAV picker:
+// its name, with its URL added when another calendar has the same name
+func calendarPickerLabels(calendars []calendar.CalDAVCalendar) []string {
+	names := make(map[string]int)
+	for _, cal := range calendars {
+		names[cal.Name]++
+	}
+
+	labels := make([]string, len(calendars))
+	used := make(map[string]bool)
+	for i, cal := range calendars {
+		label := cal.Name
+		if names[cal.Name] > 1 {
+			label = fmt.Sprintf("%s (%s)", cal.Name, cal.URL)
+		}
+		// Servers listing one URL twice still get distinct checkboxes
+		for n := 2; used[label]; n++ {
+			label = fmt.Sprintf("%s (%s) #%d", cal.Name, cal.URL, n)
+		}
+		used[label] = true
+		labels[i] = label
+	}
+	return labels
+}
+
+// calendarNameFromURL names a calendar by the last segment of its URL, e.g. "work"
+// for https://dav.example.com/calendars/alice/work/
+func calendarNameFromURL(calendarURL string) string {
+	parsed, err := url.Parse(calendarURL)
+	if err != nil {
+		return calendarURL
+	}
+	name := path.Base(strings.TrimRight(parsed.Path, "/"))
+	if name == "." || name == "/" {
+		return calendarURL
+	}
+	return name
+}
@@ -2,13 +2,18 @@ package main
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/borgmon/focus-breaker/pkg/models"
+	"github.com/borgmon/focus-breaker/pkg/store"
 )
 
 func (cw *ConfigWindow) buildEventsTab() fyne.CanvasObject {
@@ -18,7 +23,7 @@ func (cw *ConfigWindow) buildEventsTab() fyne.CanvasObject {
 	// Create table widget
 	table := widget.NewTable(
 		func() (rows int, cols int) {
-			return len(cw.eventsData), 5
+			return len(cw.eventsData), 7
 		},
 		func() fyne.CanvasObject {
 			label := widget.NewLabel("Template")
@@ -52,22 +57,26 @@ func (cw *ConfigWindow) buildEventsTab() fyne.CanvasObject {
 			case 1:
 				label.SetText(sourceName)
 			case 2:
-				label.SetText(event.StartTime.Format("Mon Jan 2, 3:04 PM"))
+				label.SetText(formatEventStart(event))
 			case 3:
-				label.SetText(displayInfo.alertStatus)
+				label.SetText(event.Location)
 			case 4:
+				label.SetText(event.Organizer.DisplayName())
+			case 5:
+				label.SetText(displayInfo.alertStatus)
+			case 6:
 				label.SetText(displayInfo.reason)
 			}
 
 			// Gray out past events
-			if event.StartTime.Before(time.Now()) {
+			if event.StartTime.Before(cw.clock.Now()) {
 				label.Importance = widget.LowImportance
 			} else {
 				label.Importance = widget.MediumImportance
 			}
 
 			// Color code alert status
-			if id.Col == 3 {
+			if id.Col == 5 {
 				switch displayInfo.alertStatus {
 				case "Alerted":
 					label.Importance = widget.SuccessImportance
@@ -99,12 +108,24 @@ func (cw *ConfigWindow) buildEventsTab() fyne.CanvasObject {
 		case 2:
 			label.SetText("Start Time")
 		case 3:
-			label.SetText("Alert Status")
+			label.SetText("Location")
 		case 4:
+			label.SetText("Organizer")
+		case 5:
+			label.SetText("Alert Status")
+		case 6:
 			label.SetText("Reason")
 		}
 	}
 
+	// Show all details of an event when its row is clicked
+	table.OnSelected = func(id widget.TableCellID) {
+		if id.Row >= 0 && id.Row < len(cw.eventsData) {
+			cw.showEventDetails(cw.eventsData[id.Row].event)
+		}
+		table.UnselectAll()
+	}
+
 	// Calculate column widths
 	cw.updateEventsColumnWidths(table)
 
@@ -116,7 +137,7 @@ func (cw *ConfigWindow) buildEventsTab() fyne.CanvasObject {
 	})
 	refreshButton.Icon = theme.ViewRefreshIcon()
 
-	helpText := widget.NewLabel("Shows all events from your calendars with their alert status. 'Alerted' means alerts are scheduled, 'Filtered' means alerts are suppressed, and 'Pending' means alerts are waiting to fire.")
+	helpText := widget.NewLabel("Shows all events from your calendars with their alert status. 'Alerted' means alerts are scheduled, 'Filtered' means alerts are suppressed or the event was skipped while syncing, and 'Pending' means alerts are waiting to fire. Click an event for details.")
 	helpText.Wrapping = fyne.TextWrapWord
 	helpText.Importance = widget.MediumImportance
 
@@ -184,8 +205,8 @@ func (cw *ConfigWindow) refreshEventsData() {
 
 func (cw *ConfigWindow) updateEventsColumnWidths(table *widget.Table) {
 	// Calculate maximum width needed for each column
-	headers := []string{"Event", "Calendar", "Start Time", "Alert Status", "Reason"}
-	columnWidths := make([]float32, 5)
+	headers := []string{"Event", "Calendar", "Start Time", "Location", "Organizer", "Alert Status", "Reason"}
+	columnWidths := make([]float32, len(headers))
 
 	charWidth := float32(8)
 	padding := float32(20)
@@ -208,7 +229,9 @@ func (cw *ConfigWindow) updateEventsColumnWidths(table *widget.Table) {
 		widths := []int{
 			len(displayInfo.event.Title),
 			len(sourceName),
-			len(displayInfo.event.StartTime.Format("Mon Jan 2, 3:04 PM")),
+			len(formatEventStart(displayInfo.event)),
+			len(displayInfo.event.Location),
+			len(displayInfo.event.Organizer.DisplayName()),
 			len(displayInfo.alertStatus),
 			len(displayInfo.reason),
 		}
@@ -222,8 +245,8 @@ func (cw *ConfigWindow) updateEventsColumnWidths(table *widget.Table) {
 	}
 
 	// Set minimum and maximum widths
-	minWidths := []float32{150, 100, 180, 100, 200}
-	maxWidths := []float32{400, 200, 200, 120, 400}
+	minWidths := []float32{150, 100, 180, 100, 100, 100, 200}
+	maxWidths := []float32{400, 200, 200, 250, 200, 120, 400}
 
 	for i := range columnWidths {
 		if columnWidths[i] < minWidths[i] {
@@ -241,45 +264,51 @@ func (cw *ConfigWindow) getEventsDisplayInfo() []eventDisplayInfo {
 		return []eventDisplayInfo{}
 	}
 
-	// Get all scheduled alerts
-	allAlerts := cw.alertStore.GetAllScheduledAlerts()
-
-	// Build a map of event ID to alert information
-	eventAlerts := make(map[string][]models.AlertStatus)
-	for _, alert := range allAlerts {
-		eventAlerts[alert.EventID] = append(eventAlerts[alert.EventID], alert.Status)
-	}
-
-	// Get all events from alert store
 	result := []eventDisplayInfo{}
-	now := time.Now()
-	cutoffTime := now.Add(-12 * time.Hour)
+	cutoffTime := cw.clock.Now().Add(-12 * time.Hour)
 
-	// We need to access the internal events map - let's iterate through alerts to find events
-	seenEvents := make(map[string]bool)
-	for _, alert := range allAlerts {
-		if seenEvents[alert.EventID] {
+	// Events stay listed after their alerts are gone
+	for _, event := range cw.alertStore.Events(store.AlertQuery{From: cutoffTime}) {
+		if event.StartTime.Before(cutoffTime) {
 			continue
 		}
-		seenEvents[alert.EventID] = true
 
-		event := cw.alertStore.GetEvent(alert.EventID)
-		if event == nil || event.StartTime.Before(cutoffTime) {
-			continue
+		alertStatuses := []models.AlertStatus{}
+		for _, alert := range cw.alertStore.AlertsForEvent(event.ID) {
+			alertStatuses = append(alertStatuses, alert.Status)
+		}
+		result = append(result, cw.determineEventStatus(&event, alertStatuses))
+	}
+
+	// Events dropped while syncing never reach the alert store. A dropped duplicate
+	// shares its ID with the event that was kept, so both rows are listed.
+	if cw.filtered != nil {
+		for _, filtered := range cw.filtered.GetAll() {
+			if filtered.Event.StartTime.Before(cutoffTime) {
+				continue
+			}
+
+			event := filtered.Event
+			result = append(result, eventDisplayInfo{
+				event:       &event,
+				alertStatus: "Filtered",
+				reason:      filtered.Reason,
+			})
 		}
 
-		displayInfo := cw.determineEventStatus(event, eventAlerts[alert.EventID])
-		result = append(result, displayInfo)
+		sort.SliceStable(result, func(i, j int) bool {
+			return result[i].event.StartTime.Before(result[j].event.StartTime)
+		})
 	}
 
 	return result
 }
 
 func (cw *ConfigWindow) determineEventStatus(event *models.Event, alertStatuses []models.AlertStatus) eventDisplayInfo {
-	now := time.Now()
+	now := cw.clock.Now()
 
-	// Check if event is in the past
-	if event.StartTime.Before(now) {
+	// Check if event is in the past (all-day events are alerted later in the day)
+	if event.AlertBase().Before(now) {
 		return eventDisplayInfo{
 			event:       event,
 			alertStatus: "Alerted",
@@ -287,12 +316,12 @@ func (cw *ConfigWindow) determineEventStatus(event *models.Event, alertStatuses
 		}
 	}
 
-	// Check if event is unaccepted and we're not notifying for unaccepted
-	if event.Status == "NEEDS-ACTION" && !cw.config.NotifyUnaccepted {
+	// Check if my response to the invitation is one we don't alert for
+	if response := event.MyResponse(); !cw.config.Participation.Allows(response) {
 		return eventDisplayInfo{
 			event:       event,
 			alertStatus: "Filtered",
-			reason:      "Event not accepted (NEEDS-ACTION)",
+			reason:      fmt.Sprintf("Your response is %s", response),
 		}
 	}
 
@@ -336,7 +365,7 @@ func (cw *ConfigWindow) determineEventStatus(event *models.Event, alertStatuses
 		// Check if it's because of quiet time
 		alertMinutes := cw.config.GetAlertMinutes()
 		for _, minutes := range alertMinutes {
-			alertTime := event.StartTime.Add(-time.Duration(minutes) * time.Minute)
+			alertTime := event.AlertBase().Add(-time.Duration(minutes) * time.Minute)
 			if cw.config.IsTimeInQuietTime(alertTime) {
 				return eventDisplayInfo{
 					event:       event,
@@ -368,6 +397,33 @@ func (cw *ConfigWindow) determineEventStatus(event *models.Event, alertStatuses
 	}
 }
 
+// formatEventStart formats the start of an event for the events table
+func formatEventStart(event *models.Event) string {
+	if event.AllDay {
+		return event.StartTime.Format("Mon Jan 2") + ", all day"
+	}
+	start := event.StartTime.Format("Mon Jan 2, 3:04 PM")
+	if original := formatOriginalStart(event); original != "" {
+		start += " (" + original + ")"
+	}
+	return start
+}
+
+// formatOriginalStart formats the start of an event in the zone it was written in,
+// e.g. "9:00 AM Europe/Berlin", or returns "" if that zone is the same as local time
+func formatOriginalStart(event *models.Event) string {
+	original, ok := event.OriginalStart()
+	if !ok {
+		return ""
+	}
+
+	layout := "3:04 PM"
+	if original.Format("20060102") != event.StartTime.Format("20060102") {
+		layout = "Mon 3:04 PM"
+	}
+	return original.Format(layout) + " " + event.Timezone
+}
+
 func countStatus(statuses []models.AlertStatus, target models.AlertStatus) int {
 	count := 0
 	for _, status := range statuses {
@@ -377,3 +433,88 @@ func countStatus(statuses []models.AlertStatus, target models.AlertStatus) int {
 	}
 	return count
 }
+
+// showEventDetails shows every field of an event in a dialog
+func (cw *ConfigWindow) showEventDetails(event *models.Event) {
+	sourceName := ""
+	for _, source := range cw.config.ICalSources {
+		if source.ID == event.SourceID {
+			sourceName = source.Name
+			break
+		}
+	}
+
+	details := widget.NewForm()
+	addRow := func(name, value string) {
+		if value == "" {
+			return
+		}
+		valueLabel := widget.NewLabel(value)
+		valueLabel.Wrapping = fyne.TextWrapWord
+		details.Append(name, valueLabel)
+	}
+
+	addRow("Calendar", sourceName)
+	if event.AllDay {
+		addRow("When", formatEventStart(event))
+		if !event.AlertAt.IsZero() {
+			addRow("Alert At", event.AlertAt.Format("3:04 PM"))
+		}
+	} else {
+		addRow("When", fmt.Sprintf("%s - %s",
+			event.StartTime.Format("Mon Jan 2, 3:04 PM"), event.EndTime.Format("3:04 PM")))
+		addRow("Original Time", formatOriginalStart(event))
+	}
+	addRow("Location", event.Location)
+	meetingLinks := []string{}
+	for _, conference := range event.Conferences {
+		meetingLinks = append(meetingLinks, fmt.Sprintf("%s: %s", conference.Provider, conference.URL))
+	}
+	if len(meetingLinks) == 0 {
+		meetingLinks = append(meetingLinks, event.MeetingLink)
+	}
+	addRow("Meeting Links", strings.Join(meetingLinks, "\n"))
+
+	dialIns := []string{}
+	for _, dialIn := range event.DialIns {
+		dialIns = append(dialIns, strings.TrimSpace(dialIn.Number+" "+dialIn.Label))
+	}
+	addRow("Dial-in", strings.Join(dialIns, "\n"))
+	addRow("Meeting ID", event.MeetingID)
+	addRow("Passcode", event.Passcode)
+	addRow("URL", event.URL)
+	addRow("Organizer", formatAttendee(event.Organizer))
+
+	attendees := []string{}
+	for _, attendee := range event.Attendees {
+		attendees = append(attendees, fmt.Sprintf("%s (%s)", formatAttendee(attendee), attendee.PartStat))
+	}
+	addRow("Attendees", strings.Join(attendees, "\n"))
+	addRow("My Response", string(event.MyResponse()))
+	addRow("Categories", strings.Join(event.Categories, ", "))
+	addRow("Status", event.Status)
+
+	availability := "Busy"
+	if event.IsFree() {
+		availability = "Free"
+	}
+	addRow("Show As", availability)
+	if event.Priority > 0 {
+		addRow("Priority", strconv.Itoa(event.Priority))
+	}
+	addRow("Class", event.Class)
+	addRow("Description", event.Description)
+
+	scroll := container.NewVScroll(details)
+	scroll.SetMinSize(fyne.NewSize(500, 400))
+
+	dialog.ShowCustom(event.Title, "Close", scroll, cw.window)
+}
+
+// formatAttendee formats an attendee as "Name <email>"
+func formatAttendee(attendee models.Attendee) string {
+	if attendee.Name != "" && attendee.Email != "" {
+		return fmt.Sprintf("%s <%s>", attendee.Name, attendee.Email)
+	}
+	return attendee.DisplayName()
+}
@@ -9,6 +9,7 @@ import (
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/borgmon/focus-breaker/pkg/models"
+	"github.com/borgmon/focus-breaker/pkg/store"
 )
 
 func (cw *ConfigWindow) buildSchedulesTab() fyne.CanvasObject {
@@ -87,7 +88,7 @@ func (cw *ConfigWindow) buildSchedulesTab() fyne.CanvasObject {
 			}
 
 			// Gray out past alerts
-			if schedule.AlertTime.Before(time.Now()) {
+			if schedule.AlertTime.Before(cw.clock.Now()) {
 				label.Importance = widget.LowImportance
 			} else {
 				label.Importance = widget.MediumImportance
@@ -278,11 +279,11 @@ func (cw *ConfigWindow) updateSchedulesColumnWidths(table *widget.Table) {
 	}
 }
 
-func (cw *ConfigWindow) getScheduledAlerts() []*models.ScheduledAlert {
+func (cw *ConfigWindow) getScheduledAlerts() []models.ScheduledAlert {
 	if cw.alertStore == nil {
-		return []*models.ScheduledAlert{}
+		return []models.ScheduledAlert{}
 	}
 
-	// Get all scheduled alerts directly from eventstore (already sorted by alert time)
-	return cw.alertStore.GetAllScheduledAlerts()
+	// Upcoming alerts and those of the last 12 hours, sorted by alert time
+	return cw.alertStore.Alerts(store.AlertQuery{From: cw.clock.Now().Add(-12 * time.Hour)})
 }
@@ -3,12 +3,14 @@ package main
 import (
 	"fmt"
 	"log"
+	"reflect"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
+	"github.com/borgmon/focus-breaker/pkg/clock"
 	"github.com/borgmon/focus-breaker/pkg/models"
 	"github.com/borgmon/focus-breaker/pkg/platform"
 	"github.com/borgmon/focus-breaker/pkg/store"
@@ -17,6 +19,7 @@ import (
 type ConfigWindow struct {
 	window fyne.Window
 	app    fyne.App
+	clock  clock.Clock
 	config *models.Config
 	onSave func(*models.Config)
 
@@ -25,21 +28,29 @@ type ConfigWindow struct {
 	icalSourcesList      *widget.List
 	icalSourcesData      []models.ICalSource
 	updateIntervalSelect *widget.Select
+	lookaheadSelect      *widget.Select
+	sourceTimeoutSelect  *widget.Select
+	staleWarningSelect   *widget.Select
+	meetingPatternsEntry *widget.Entry
+	nativeAppChecks      *widget.CheckGroup
 	syncNowButton        *widget.Button
+	healthStore          *store.SyncHealthStore
 
 	// Alert tab
-	snoozeTimeSelect      *widget.Select
-	notifyUnacceptedCheck *widget.Check
-	alertBeforeList       *widget.List
-	alertBeforeData       []string
-	alertBeforeContainer  *fyne.Container
-	holdTimeSelect        *widget.Select
-	quietTimeList         *widget.List
-	quietTimeData         []models.TimeRange
+	snoozeTimeSelect     *widget.Select
+	missedGraceSelect    *widget.Select
+	participationChecks  *widget.CheckGroup
+	alertBeforeList      *widget.List
+	alertBeforeData      []string
+	alertBeforeContainer *fyne.Container
+	alarmSourceSelect    *widget.Select
+	holdTimeSelect       *widget.Select
+	quietTimeList        *widget.List
+	quietTimeData        []models.TimeRange
 
 	// Schedules tab
 	schedulesTable      *widget.Table
-	schedulesData       []*models.ScheduledAlert
+	schedulesData       []models.ScheduledAlert
 	schedulesContainer  *fyne.Container
 	selectedScheduleRow int
 	alertStore          *store.AlertStore
@@ -48,6 +59,7 @@ type ConfigWindow struct {
 	eventsTable     *widget.Table
 	eventsData      []eventDisplayInfo
 	eventsContainer *fyne.Container
+	filtered        *store.FilteredEventStore
 
 	// UI state
 	hasUnsavedChanges bool
@@ -62,12 +74,15 @@ type eventDisplayInfo struct {
 	reason      string // Reason for the status
 }
 
-func NewConfigWindow(app fyne.App, config *models.Config, alertStore *store.AlertStore, onSave func(*models.Config)) *ConfigWindow {
+func NewConfigWindow(app fyne.App, clk clock.Clock, config *models.Config, alertStore *store.AlertStore, healthStore *store.SyncHealthStore, filtered *store.FilteredEventStore, onSave func(*models.Config)) *ConfigWindow {
 	cw := &ConfigWindow{
-		app:        app,
-		config:     config,
-		alertStore: alertStore,
-		onSave:     onSave,
+		app:         app,
+		clock:       clk,
+		config:      config,
+		alertStore:  alertStore,
+		healthStore: healthStore,
+		filtered:    filtered,
+		onSave:      onSave,
 	}
 
 	cw.window = app.NewWindow("Focus Breaker - Settings")
@@ -91,6 +106,12 @@ func (cw *ConfigWindow) buildUI() {
 
 	// Bottom buttons
 	cw.saveButton = widget.NewButton("Save", func() {
+		// A bad line would otherwise drop every custom provider
+		if err := cw.meetingPatternsEntry.Validate(); err != nil {
+			dialog.ShowError(fmt.Errorf("Custom meeting providers: %w", err), cw.window)
+			return
+		}
+
 		cw.saveButton.Disable()
 		cw.saveStatusLabel.SetText("Saving...")
 		cw.saveStatusLabel.Importance = widget.MediumImportance
@@ -144,8 +165,8 @@ func (cw *ConfigWindow) buildUI() {
 		sampleEvent := models.Event{
 			Title:       "Sample Meeting",
 			Description: "This is a preview of how meeting alerts will appear. You can customize the alert timing and snooze settings in the Alert tab.",
-			StartTime:   time.Now(),
-			EndTime:     time.Now().Add(30 * time.Minute),
+			StartTime:   cw.clock.Now(),
+			EndTime:     cw.clock.Now().Add(30 * time.Minute),
 			MeetingLink: "https://meet.example.com/sample",
 			Status:      "CONFIRMED",
 		}
@@ -170,7 +191,7 @@ func (cw *ConfigWindow) buildUI() {
 			}
 		}
 
-		alertWindow := NewAlertWindow(cw.app, sampleEvent, snoozeTime, holdTimeSeconds, func() {
+		alertWindow := NewAlertWindow(cw.app, sampleEvent, snoozeTime, holdTimeSeconds, nil, func() {
 		}, func() {
 		})
 		alertWindow.Show()
@@ -234,6 +255,37 @@ func (cw *ConfigWindow) getConfigFromUI() *models.Config {
 		}
 	}
 
+	lookaheadDays := 1
+	if cw.lookaheadSelect.Selected != "" {
+		// Parse "3 days" -> 3
+		var val int
+		if _, err := fmt.Sscanf(cw.lookaheadSelect.Selected, "%d day", &val); err == nil {
+			lookaheadDays = val
+		}
+	}
+
+	sourceTimeout := 30
+	if cw.sourceTimeoutSelect.Selected != "" {
+		// Parse "30 sec" -> 30
+		var val int
+		if _, err := fmt.Sscanf(cw.sourceTimeoutSelect.Selected, "%d sec", &val); err == nil {
+			sourceTimeout = val
+		}
+	}
+
+	staleWarningHours := 6
+	if cw.staleWarningSelect.Selected != "" {
+		if cw.staleWarningSelect.Selected == staleWarningOffLabel {
+			staleWarningHours = 0
+		} else {
+			// Parse "6 hours" -> 6
+			var val int
+			if _, err := fmt.Sscanf(cw.staleWarningSelect.Selected, "%d hour", &val); err == nil {
+				staleWarningHours = val
+			}
+		}
+	}
+
 	snoozeTime := 4
 	if cw.snoozeTimeSelect.Selected != "" {
 		if cw.snoozeTimeSelect.Selected == "0 min (disabled)" {
@@ -247,6 +299,19 @@ func (cw *ConfigWindow) getConfigFromUI() *models.Config {
 		}
 	}
 
+	missedAlertGrace := 15
+	if cw.missedGraceSelect.Selected != "" {
+		if cw.missedGraceSelect.Selected == missedGraceOffLabel {
+			missedAlertGrace = 0
+		} else {
+			// Parse "15 min" -> 15
+			var val int
+			if _, err := fmt.Sscanf(cw.missedGraceSelect.Selected, "%d min", &val); err == nil {
+				missedAlertGrace = val
+			}
+		}
+	}
+
 	// Convert alertBeforeData to comma-separated string
 	alertBeforeMin := ""
 	for i, val := range cw.alertBeforeData {
@@ -265,15 +330,28 @@ func (cw *ConfigWindow) getConfigFromUI() *models.Config {
 		}
 	}
 
+	// Save refuses invalid lines; until they are fixed the saved providers stand
+	meetingProviders, err := parseMeetingProviderLines(cw.meetingPatternsEntry.Text)
+	if err != nil {
+		meetingProviders = cw.config.MeetingProviders
+	}
+
 	return &models.Config{
-		AutoStart:        cw.autoStartCheck.Checked,
-		ICalSources:      cw.icalSourcesData,
-		UpdateInterval:   updateInterval,
-		SnoozeTime:       snoozeTime,
-		NotifyUnaccepted: cw.notifyUnacceptedCheck.Checked,
-		AlertBeforeMin:   alertBeforeMin,
-		HoldTimeSeconds:  holdTimeSeconds,
-		QuietTimeRanges:  cw.quietTimeData,
+		AutoStart:         cw.autoStartCheck.Checked,
+		ICalSources:       cw.icalSourcesData,
+		UpdateInterval:    updateInterval,
+		LookaheadDays:     lookaheadDays,
+		SourceTimeout:     sourceTimeout,
+		StaleWarningHours: staleWarningHours,
+		SnoozeTime:        snoozeTime,
+		MissedAlertGrace:  missedAlertGrace,
+		Participation:     cw.getParticipationFromUI(),
+		AlertBeforeMin:    alertBeforeMin,
+		AlarmSource:       alarmSourceFromLabel(cw.alarmSourceSelect.Selected),
+		MeetingProviders:  meetingProviders,
+		NativeApps:        cw.nativeAppChecks.Selected,
+		HoldTimeSeconds:   holdTimeSeconds,
+		QuietTimeRanges:   cw.quietTimeData,
 	}
 }
 
@@ -328,13 +406,33 @@ func (cw *ConfigWindow) hasActualChanges() bool {
 		return true
 	}
 
+	// Compare lookahead
+	if currentConfig.LookaheadDays != cw.config.LookaheadDays {
+		return true
+	}
+
+	// Compare source timeout
+	if currentConfig.SourceTimeout != cw.config.SourceTimeout {
+		return true
+	}
+
+	// Compare stale warning threshold
+	if currentConfig.StaleWarningHours != cw.config.StaleWarningHours {
+		return true
+	}
+
 	// Compare snooze time
 	if currentConfig.SnoozeTime != cw.config.SnoozeTime {
 		return true
 	}
 
-	// Compare notify unaccepted
-	if currentConfig.NotifyUnaccepted != cw.config.NotifyUnaccepted {
+	// Compare missed alert grace
+	if currentConfig.MissedAlertGrace != cw.config.MissedAlertGrace {
+		return true
+	}
+
+	// Compare participation policy
+	if currentConfig.Participation != cw.config.Participation {
 		return true
 	}
 
@@ -343,6 +441,21 @@ func (cw *ConfigWindow) hasActualChanges() bool {
 		return true
 	}
 
+	// Compare custom meeting providers
+	if !reflect.DeepEqual(currentConfig.MeetingProviders, cw.config.MeetingProviders) {
+		return true
+	}
+
+	// Compare native app providers
+	if !reflect.DeepEqual(currentConfig.NativeApps, cw.config.NativeApps) {
+		return true
+	}
+
+	// Compare alarm source
+	if currentConfig.AlarmSource != cw.config.AlarmSource {
+		return true
+	}
+
 	// Compare iCal sources - check length first
 	if len(currentConfig.ICalSources) != len(cw.config.ICalSources) {
 		return true
@@ -350,9 +463,7 @@ func (cw *ConfigWindow) hasActualChanges() bool {
 
 	// Compare each iCal source
 	for i := range currentConfig.ICalSources {
-		if currentConfig.ICalSources[i].ID != cw.config.ICalSources[i].ID ||
-			currentConfig.ICalSources[i].Name != cw.config.ICalSources[i].Name ||
-			currentConfig.ICalSources[i].URL != cw.config.ICalSources[i].URL {
+		if !reflect.DeepEqual(currentConfig.ICalSources[i], cw.config.ICalSources[i]) {
 			return true
 		}
 	}
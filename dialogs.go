@@ -49,11 +49,11 @@ func (cw *ConfigWindow) showAddAlarmDialog() {
 
 		// Calculate alarm time
 		totalMinutes := hours*60 + mins
-		alarmTime := time.Now().Add(time.Duration(totalMinutes) * time.Minute)
+		alarmTime := cw.clock.Now().Add(time.Duration(totalMinutes) * time.Minute)
 
 		// Create a manual event
 		event := &models.Event{
-			ID:          fmt.Sprintf("manual-%s", time.Now().Format("20060102-150405")),
+			ID:          fmt.Sprintf("manual-%s", cw.clock.Now().Format("20060102-150405")),
 			Title:       fmt.Sprintf("Manual Alarm (%dh %dm)", hours, mins),
 			Description: "Manually created countdown alarm",
 			StartTime:   alarmTime,
@@ -1,14 +1,23 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"github.com/borgmon/focus-breaker/pkg/calendar"
+	"github.com/borgmon/focus-breaker/pkg/clock"
 	"github.com/borgmon/focus-breaker/pkg/models"
 	"github.com/borgmon/focus-breaker/pkg/platform"
+	"github.com/borgmon/focus-breaker/pkg/scheduler"
 	"github.com/borgmon/focus-breaker/pkg/store"
 )
 
@@ -16,16 +25,46 @@ type FocusBreaker struct {
 	app          fyne.App
 	config       *models.Config
 	alertStore   *store.AlertStore
-	syncTicker   *time.Ticker
-	alertTicker  *time.Ticker
+	feedCache    *calendar.FeedCache
+	healthStore  *store.SyncHealthStore
+	filtered     *store.FilteredEventStore
+	fileWatcher  *calendar.FileWatcher
 	configWindow *ConfigWindow
+
+	// ctx is cancelled on quit; syncCancel cancels the sync currently in flight and
+	// stopSyncLoop the periodic sync
+	ctx          context.Context
+	cancel       context.CancelFunc
+	syncMu       sync.Mutex
+	syncCancel   context.CancelFunc
+	stopSyncLoop context.CancelFunc
+
+	// Sources that have already been warned about as stale, so each episode warns once
+	staleMu     sync.Mutex
+	staleWarned map[string]bool
+
+	// Time source of the stores, the calendar fetches and the alert scheduler
+	clock     clock.Clock
+	scheduler *scheduler.AlertScheduler
 }
 
 func main() {
+	if simulateFromCommandLine(os.Args[1:]) {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
 	fb := &FocusBreaker{
-		app:        app.New(),
-		alertStore: store.NewAlertStore(),
+		app:         app.New(),
+		alertStore:  store.NewAlertStore(clock.Real),
+		healthStore: store.NewSyncHealthStore(clock.Real),
+		filtered:    store.NewFilteredEventStore(),
+		staleWarned: make(map[string]bool),
+		ctx:         ctx,
+		cancel:      cancel,
+		clock:       clock.Real,
 	}
+	fb.scheduler = scheduler.NewAlertScheduler(fb.clock, fb.alertStore, func() *models.Config { return fb.config }, fb.showDueAlerts)
 
 	if err := fb.initialize(); err != nil {
 		log.Fatal(err)
@@ -45,9 +84,33 @@ func (fb *FocusBreaker) initialize() error {
 
 	configStore.Save(fb.config)
 
+	fb.applyMeetingProviders()
+
+	// Keep the last good copy of every feed so alerts survive network outages and restarts
+	fb.feedCache = calendar.NewFeedCache(filepath.Join(fb.app.Storage().RootURI().Path(), "feed_cache"))
+
+	// Restore dismissed and snoozed alerts and manual alarms before the first sync,
+	// so it doesn't alert again for what was already handled
+	if err := fb.alertStore.Load(filepath.Join(fb.app.Storage().RootURI().Path(), "alerts.json")); err != nil {
+		log.Printf("Warning: starting with no saved alerts: %v", err)
+	}
+
+	// Reload local file:// sources as soon as their .ics files change
+	fileWatcher, err := calendar.NewFileWatcher(fb.syncLocalSource)
+	if err != nil {
+		log.Printf("Warning: failed to start file watcher: %v", err)
+	} else {
+		fb.fileWatcher = fileWatcher
+		fb.fileWatcher.Watch(fb.config.ICalSources)
+	}
+
 	fb.setupSystemTray()
-	fb.startBackgroundSync() // This will sync and update the tray menu
-	fb.startAlertChecker()
+
+	// The first sync runs in the background so a slow feed cannot hold up startup;
+	// alerts restored from disk fire meanwhile
+	go fb.syncEvents()
+	fb.startBackgroundSync()
+	fb.startAlertScheduler()
 
 	if fb.config.NeedsConfiguration() {
 		fb.showConfigWindow()
@@ -72,16 +135,22 @@ func (fb *FocusBreaker) showConfigWindow() {
 	}
 
 	// Create new config window
-	fb.configWindow = NewConfigWindow(fb.app, fb.config, fb.alertStore, func(newConfig *models.Config) {
+	fb.configWindow = NewConfigWindow(fb.app, fb.clock, fb.config, fb.alertStore, fb.healthStore, fb.filtered, func(newConfig *models.Config) {
 		fb.config = newConfig
 		configStore := store.NewConfigStore(fb.app)
 		configStore.Save(fb.config)
 
 		// Update muted status for all alerts based on new quiet time settings
 		fb.alertStore.UpdateMutedStatusForQuietTime(fb.config)
+		// Participation changes decide which alert is next
+		fb.scheduler.Rearm()
+
+		fb.applyMeetingProviders()
 
-		fb.restartBackgroundSync()
+		fb.fileWatcher.Watch(fb.config.ICalSources)
 
+		// Sync once now, then every update interval from now on
+		fb.startBackgroundSync()
 		if !fb.config.NeedsConfiguration() {
 			fb.syncEvents()
 		}
@@ -99,116 +168,326 @@ func (fb *FocusBreaker) showConfigWindow() {
 	fb.configWindow.Show()
 }
 
+// applyMeetingProviders registers the custom meeting link patterns from config
+func (fb *FocusBreaker) applyMeetingProviders() {
+	if err := calendar.SetCustomMeetingProviders(fb.config.MeetingProviders); err != nil {
+		log.Printf("Warning: ignoring custom meeting providers: %v", err)
+	}
+}
+
+// sourceResult is the outcome of fetching one source during a sync
+type sourceResult struct {
+	result *calendar.FetchResult
+	err    error
+}
+
+// beginSync cancels any sync still in flight and returns the context for a new one
+func (fb *FocusBreaker) beginSync() (context.Context, context.CancelFunc) {
+	fb.syncMu.Lock()
+	defer fb.syncMu.Unlock()
+
+	if fb.syncCancel != nil {
+		fb.syncCancel()
+	}
+	ctx, cancel := context.WithCancel(fb.ctx)
+	fb.syncCancel = cancel
+	return ctx, cancel
+}
+
+// sourceTimeout returns the per-source fetch timeout from config
+func (fb *FocusBreaker) sourceTimeout() time.Duration {
+	if fb.config.SourceTimeout <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(fb.config.SourceTimeout) * time.Second
+}
+
 func (fb *FocusBreaker) syncEvents() {
 	log.Println("=== Starting sync process ===")
 
-	if len(fb.config.ICalSources) == 0 {
+	sources := fb.config.ICalSources
+	if len(sources) == 0 {
 		log.Println("No iCal sources configured")
 		return
 	}
 
-	log.Printf("Found %d iCal source(s) to sync", len(fb.config.ICalSources))
+	ctx, cancel := fb.beginSync()
+	defer cancel()
 
-	// Collect events from all iCal sources
+	policy := calendar.DefaultRetryPolicy
+	policy.AttemptTimeout = fb.sourceTimeout()
+	lookahead := fb.config.GetLookahead()
+	log.Printf("Found %d iCal source(s) to sync (timeout: %v per attempt, %d attempts, lookahead: %v)",
+		len(sources), policy.AttemptTimeout, policy.MaxAttempts, lookahead)
+
+	// Fetch all sources concurrently so one slow feed cannot hold up the others
+	results := make([]sourceResult, len(sources))
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		if !source.Validate() {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, source models.ICalSource) {
+			defer wg.Done()
+
+			log.Printf("Fetching events from '%s' (%s)", source.Name, source.URL)
+			result, err := calendar.FetchEventsWithRetry(ctx, fb.clock, source, fb.feedCache, policy, lookahead)
+			results[i] = sourceResult{result: result, err: err}
+		}(i, source)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		log.Println("=== Sync cancelled (superseded or shutting down) ===")
+		return
+	}
+
+	// Collect events from all iCal sources, and which sources they are complete for
 	allEvents := []models.Event{}
+	syncedSources := []string{}
 	successfulSources := 0
 	failedSources := 0
 
-	for i, source := range fb.config.ICalSources {
-		log.Printf("Processing source %d/%d: '%s'", i+1, len(fb.config.ICalSources), source.Name)
+	for i, source := range sources {
+		log.Printf("Processing source %d/%d: '%s'", i+1, len(sources), source.Name)
 
 		if !source.Validate() {
 			log.Printf("Skipping invalid source '%s' (missing name or URL)", source.Name)
+			fb.healthStore.RecordFailure(source.ID, errors.New("missing name or URL"), -1, time.Time{})
 			failedSources++
 			continue
 		}
 
-		log.Printf("Fetching events from '%s' (%s)", source.Name, source.URL)
-		events, err := calendar.FetchEvents(source)
+		result, err := results[i].result, results[i].err
+		var fallbackErr *calendar.CacheFallbackError
+		if errors.As(err, &fallbackErr) {
+			// Fetch failed but the cached copy still provides events
+			log.Printf("Error fetching iCal source '%s' (%s), %v", source.Name, source.URL, err)
+			fb.healthStore.RecordFailure(source.ID, fallbackErr.Err, len(result.Events), fallbackErr.FetchedAt)
+			fb.filtered.Set(source.ID, result.Filtered)
+			allEvents = append(allEvents, result.Events...)
+			syncedSources = append(syncedSources, source.ID)
+			failedSources++
+			continue
+		}
 		if err != nil {
 			log.Printf("Error fetching iCal source '%s' (%s): %v", source.Name, source.URL, err)
+			fb.healthStore.RecordFailure(source.ID, err, -1, time.Time{})
 			failedSources++
 			continue
 		}
 
-		allEvents = append(allEvents, events...)
+		fb.healthStore.RecordSuccess(source.ID, len(result.Events), result.FeedEvents)
+		fb.filtered.Set(source.ID, result.Filtered)
+		allEvents = append(allEvents, result.Events...)
+		syncedSources = append(syncedSources, source.ID)
 		successfulSources++
-		log.Printf("Successfully synced %d events from '%s'", len(events), source.Name)
+		log.Printf("Successfully synced %d events from '%s'", len(result.Events), source.Name)
 	}
 
+	// Drop cached feeds, health and filtered events of sources that were removed from the config
+	fb.feedCache.Prune(sources)
+	fb.healthStore.Prune(sources)
+	fb.filtered.Prune(sources)
+
 	log.Printf("Sync completed: %d successful, %d failed out of %d total sources",
-		successfulSources, failedSources, len(fb.config.ICalSources))
+		successfulSources, failedSources, len(sources))
 
 	alertMinutes := fb.config.GetAlertMinutes()
 	log.Printf("Updating alert store with %d total events (alert offset: %d minutes)",
 		len(allEvents), alertMinutes)
 
-	fb.alertStore.UpdateEventsWithConfig(allEvents, alertMinutes, fb.config)
+	fb.alertStore.UpdateEventsWithConfig(allEvents, syncedSources, alertMinutes, fb.config)
 	log.Printf("Alert store updated successfully")
 
+	fb.checkStaleSources()
+
 	// Update system tray menu with new events
 	log.Println("Updating system tray menu")
 	fb.updateSystemTrayMenu()
 	log.Println("=== Sync process completed ===")
 }
 
-func (fb *FocusBreaker) startBackgroundSync() {
-	// Do initial sync synchronously to populate data before UI setup
-	if len(fb.config.ICalSources) > 0 {
-		fb.syncEvents()
+// syncLocalSource re-parses a single local source after its files changed on disk
+func (fb *FocusBreaker) syncLocalSource(source models.ICalSource) {
+	if !source.Validate() {
+		return
 	}
 
-	// Start periodic background sync
-	fb.syncTicker = time.NewTicker(time.Duration(fb.config.UpdateInterval) * time.Minute)
-	go func() {
-		for range fb.syncTicker.C {
-			if !fb.config.NeedsConfiguration() {
-				fb.syncEvents()
+	ctx, cancel := context.WithTimeout(fb.ctx, fb.sourceTimeout())
+	defer cancel()
+
+	result, err := calendar.FetchEvents(ctx, fb.clock, source, fb.feedCache, fb.config.GetLookahead())
+	if err != nil {
+		log.Printf("Error reloading local source '%s' (%s): %v", source.Name, source.URL, err)
+		fb.healthStore.RecordFailure(source.ID, err, -1, time.Time{})
+		fb.checkStaleSources()
+		fb.updateSystemTrayMenu()
+		return
+	}
+	fb.healthStore.RecordSuccess(source.ID, len(result.Events), result.FeedEvents)
+	fb.filtered.Set(source.ID, result.Filtered)
+
+	fb.alertStore.UpdateEventsWithConfig(result.Events, []string{source.ID}, fb.config.GetAlertMinutes(), fb.config)
+	log.Printf("Reloaded %d events from local source '%s'", len(result.Events), source.Name)
+
+	fb.checkStaleSources()
+	fb.updateSystemTrayMenu()
+}
+
+// checkStaleSources warns once per episode about sources that have silently
+// stopped syncing, since a dead feed otherwise just means alerts never fire
+func (fb *FocusBreaker) checkStaleSources() {
+	fb.staleMu.Lock()
+	defer fb.staleMu.Unlock()
+
+	now := fb.clock.Now()
+	threshold := fb.config.GetStaleWarningThreshold()
+	configured := make(map[string]bool)
+	warnings := []string{}
+
+	for _, source := range fb.config.ICalSources {
+		configured[source.ID] = true
+
+		health := fb.healthStore.Get(source.ID)
+		if health == nil {
+			continue
+		}
+
+		reason := health.StaleReason(now, threshold)
+		if reason == "" {
+			if fb.staleWarned[source.ID] {
+				log.Printf("Calendar '%s' is syncing again", source.Name)
 			}
+			delete(fb.staleWarned, source.ID)
+			continue
+		}
+		if fb.staleWarned[source.ID] {
+			continue
 		}
-	}()
-}
 
-func (fb *FocusBreaker) restartBackgroundSync() {
-	if fb.syncTicker != nil {
-		fb.syncTicker.Stop()
+		fb.staleWarned[source.ID] = true
+		log.Printf("Warning: calendar '%s' %s", source.Name, reason)
+		fb.app.SendNotification(fyne.NewNotification(
+			"Calendar stopped syncing",
+			fmt.Sprintf("'%s' %s. Meeting alerts from it may be missing until this is fixed.", source.Name, reason),
+		))
+		warnings = append(warnings, fmt.Sprintf("'%s' %s.", source.Name, reason))
+	}
+
+	// Notifications may be muted; the tray shows the state and this window the news
+	if len(warnings) > 0 {
+		fb.showStaleWarning(warnings)
+	}
+
+	// Forget removed sources
+	for sourceID := range fb.staleWarned {
+		if !configured[sourceID] {
+			delete(fb.staleWarned, sourceID)
+		}
 	}
-	fb.startBackgroundSync()
 }
 
-func (fb *FocusBreaker) startAlertChecker() {
-	fb.alertTicker = time.NewTicker(1 * time.Minute)
+// startBackgroundSync syncs every update interval from now, stopping the loop of an
+// earlier call
+func (fb *FocusBreaker) startBackgroundSync() {
+	ctx, stop := context.WithCancel(fb.ctx)
+	fb.syncMu.Lock()
+	if fb.stopSyncLoop != nil {
+		fb.stopSyncLoop()
+	}
+	fb.stopSyncLoop = stop
+	fb.syncMu.Unlock()
+
+	ticker := fb.clock.NewTicker(time.Duration(max(fb.config.UpdateInterval, 1)) * time.Minute)
 	go func() {
-		for range fb.alertTicker.C {
-			fb.checkAlerts()
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C():
+				if !fb.config.NeedsConfiguration() {
+					fb.syncEvents()
+				}
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
+}
+
+// alertStartupDelay gives the app a moment to start before the first alert
+const alertStartupDelay = 5 * time.Second
 
+// startAlertScheduler shows alerts when they are due
+func (fb *FocusBreaker) startAlertScheduler() {
 	go func() {
-		time.Sleep(5 * time.Second)
-		fb.checkAlerts()
+		timer := fb.clock.NewTimer(alertStartupDelay)
+		select {
+		case <-timer.C():
+		case <-fb.ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		fb.scheduler.Run(fb.ctx)
 	}()
 }
 
-func (fb *FocusBreaker) checkAlerts() {
-	if fb.config.NeedsConfiguration() {
-		return
+// showDueAlerts shows an alert for each alert that is due and one combined alert
+// for those that were missed
+func (fb *FocusBreaker) showDueAlerts(due, missed []models.ScheduledAlert) {
+	for _, alert := range due {
+		fb.showAlert(alert)
 	}
+	fb.showMissedAlerts(missed)
+}
 
-	alerts := fb.alertStore.GetAlertsForCurrentMinute(fb.config.NotifyUnaccepted)
-
+// showMissedAlerts shows one combined alert for alerts that were missed
+func (fb *FocusBreaker) showMissedAlerts(alerts []models.ScheduledAlert) {
+	missed := []models.ScheduledAlert{}
+	events := []models.Event{}
+	listed := make(map[string]bool)
 	for _, alert := range alerts {
-		// Skip muted alerts - they should not be shown
-		if alert.Status == models.AlertStatusMuted {
-			log.Printf("Skipping muted alert for event: %s", alert.EventID)
+		event := fb.alertStore.GetEvent(alert.EventID)
+		if event == nil {
 			continue
 		}
 
-		fb.showAlert(alert)
+		missed = append(missed, alert)
+		// An event missed at several alert times is listed once
+		if !listed[event.ID] {
+			listed[event.ID] = true
+			events = append(events, *event)
+		}
+	}
+	if len(events) == 0 {
+		return
 	}
+
+	log.Printf("Showing %d missed alert(s) for %d event(s)", len(missed), len(events))
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].StartTime.Before(events[j].StartTime)
+	})
+
+	alertWindow := NewMissedAlertWindow(
+		fb.app,
+		events,
+		fb.clock.Now(),
+		fb.config.HoldTimeSeconds,
+		fb.openEventMeeting,
+		func() {
+			for _, alert := range missed {
+				fb.alertStore.MarkAlertStatus(alert.EventID, alert.AlertOffset, models.AlertStatusAlerted, nil)
+			}
+			log.Printf("Missed alerts closed")
+		},
+	)
+	alertWindow.Show()
 }
 
-func (fb *FocusBreaker) showAlert(alert *models.ScheduledAlert) {
+func (fb *FocusBreaker) showAlert(alert models.ScheduledAlert) {
 	// Get the actual event from the store
 	event := fb.alertStore.GetEvent(alert.EventID)
 	if event == nil {
@@ -221,6 +500,7 @@ func (fb *FocusBreaker) showAlert(alert *models.ScheduledAlert) {
 		*event,
 		fb.config.SnoozeTime,
 		fb.config.HoldTimeSeconds,
+		fb.openEventMeeting,
 		func() {
 			// Mark alert as alerted (closed/dismissed)
 			fb.alertStore.MarkAlertStatus(alert.EventID, alert.AlertOffset, models.AlertStatusAlerted, nil)
@@ -228,7 +508,7 @@ func (fb *FocusBreaker) showAlert(alert *models.ScheduledAlert) {
 		},
 		func() {
 			// Mark alert as snoozed and schedule new alert
-			snoozeUntil := time.Now().Add(time.Duration(fb.config.SnoozeTime) * time.Minute)
+			snoozeUntil := fb.clock.Now().Add(time.Duration(fb.config.SnoozeTime) * time.Minute)
 			fb.alertStore.MarkAlertStatus(alert.EventID, alert.AlertOffset, models.AlertStatusSnoozed, &snoozeUntil)
 			log.Printf("Alert snoozed for event: %s until %s", event.Title, snoozeUntil.Format(time.RFC3339))
 		},
@@ -236,12 +516,14 @@ func (fb *FocusBreaker) showAlert(alert *models.ScheduledAlert) {
 	alertWindow.Show()
 }
 
+// openEventMeeting opens a meeting link of event in the app or launcher configured for it
+func (fb *FocusBreaker) openEventMeeting(event models.Event, conference models.Conference) {
+	openMeeting(conference, fb.config, event.SourceID)
+}
+
 func (fb *FocusBreaker) quit() {
-	if fb.syncTicker != nil {
-		fb.syncTicker.Stop()
-	}
-	if fb.alertTicker != nil {
-		fb.alertTicker.Stop()
-	}
+	// Abort any in-flight sync and the sync loop before tearing down
+	fb.cancel()
+	fb.fileWatcher.Close()
 	fb.app.Quit()
 }
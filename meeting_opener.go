@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os/exec"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"github.com/borgmon/focus-breaker/pkg/calendar"
+	"github.com/borgmon/focus-breaker/pkg/models"
+)
+
+// urlPlaceholder marks where the link goes in a source's open command
+const urlPlaceholder = "{url}"
+
+// openMeeting opens a meeting link in its desktop app when enabled for the provider,
+// otherwise with the open command of the event's source or the default browser
+func openMeeting(conference models.Conference, config *models.Config, sourceID string) {
+	link := conference.URL
+
+	if config.OpensInNativeApp(conference.Provider) {
+		if native, ok := calendar.NativeAppLink(conference); ok {
+			link = native
+		}
+	}
+
+	// Native app links are handed to the OS so the app that registered the scheme opens them
+	if link == conference.URL {
+		for _, source := range config.ICalSources {
+			if source.ID != sourceID || source.OpenCommand == "" {
+				continue
+			}
+			if err := runOpenCommand(source.OpenCommand, link); err != nil {
+				log.Printf("Error opening meeting link with '%s': %v", source.OpenCommand, err)
+				break
+			}
+			return
+		}
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		log.Printf("Error parsing meeting link %s: %v", link, err)
+		return
+	}
+	if err := fyne.CurrentApp().OpenURL(u); err != nil {
+		log.Printf("Error opening meeting link %s: %v", link, err)
+	}
+}
+
+// runOpenCommand starts command with link in place of {url}, or appended if absent
+func runOpenCommand(command, link string) error {
+	args, err := splitCommandLine(command)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("empty command")
+	}
+
+	replaced := false
+	for i, arg := range args {
+		if strings.Contains(arg, urlPlaceholder) {
+			args[i] = strings.ReplaceAll(arg, urlPlaceholder, link)
+			replaced = true
+		}
+	}
+	if !replaced {
+		args = append(args, link)
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Reap the launcher when it exits; browsers usually hand off and return quickly
+	go cmd.Wait()
+	return nil
+}
+
+// splitCommandLine splits a command into arguments, honoring single and double quotes
+func splitCommandLine(command string) ([]string, error) {
+	args := []string{}
+	var current strings.Builder
+	inArg := false
+	var quote rune
+
+	for _, r := range command {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/borgmon/focus-breaker/pkg/models"
+	"github.com/borgmon/focus-breaker/pkg/ui/components"
+)
+
+// NewMissedAlertWindow creates one full-screen alert listing events whose alerts were
+// missed, e.g. while the computer was asleep, timed relative to now. onClose is called
+// when it is dismissed.
+func NewMissedAlertWindow(app fyne.App, events []models.Event, now time.Time, holdTimeSeconds int, openMeeting func(models.Event, models.Conference), onClose func()) *AlertWindow {
+	aw := &AlertWindow{
+		app:             app,
+		missed:          events,
+		missedAt:        now,
+		holdTimeSeconds: holdTimeSeconds,
+		openMeeting:     openMeeting,
+		onClose:         onClose,
+		stopMonitoring:  make(chan struct{}),
+	}
+	aw.open("Missed Alerts")
+
+	return aw
+}
+
+func (aw *AlertWindow) buildMissedUI() {
+	title := canvas.NewText("You Missed These", nil)
+	title.TextSize = 32
+	title.Alignment = fyne.TextAlignCenter
+
+	subtitle := widget.NewLabel("These alerts came due while your computer was asleep or busy")
+	subtitle.Alignment = fyne.TextAlignCenter
+
+	rows := container.NewVBox()
+	for _, event := range aw.missed {
+		row := container.NewHBox(
+			widget.NewLabelWithStyle(event.Title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabel(missedEventTiming(&event, aw.missedAt)),
+		)
+
+		// Only the best link, the list should stay short
+		if conferences := eventConferences(&event); len(conferences) > 0 {
+			joinButton := widget.NewButton(joinButtonLabel(conferences[0]), func() {
+				aw.joinMeeting(event, conferences[0])
+			})
+			joinButton.Importance = widget.HighImportance
+			row.Add(joinButton)
+		}
+		rows.Add(row)
+	}
+
+	var closeButton *components.HoldButton
+	closeButton = components.NewHoldButton(fmt.Sprintf("Close (Hold %ds)", aw.holdTimeSeconds), func() {
+		aw.startCloseProgress(closeButton)
+	}, func() {
+		aw.stopCloseProgress(closeButton)
+	})
+
+	content := container.NewVBox(
+		container.NewPadded(title),
+		subtitle,
+		widget.NewSeparator(),
+		container.NewCenter(rows),
+		widget.NewSeparator(),
+		container.NewCenter(closeButton),
+	)
+
+	aw.window.SetContent(container.NewPadded(container.NewCenter(content)))
+}
+
+// missedEventTiming describes when a missed event is relative to now, e.g.
+// "10:00 AM, started 3 min ago"
+func missedEventTiming(event *models.Event, now time.Time) string {
+	if event.AllDay {
+		return "All day"
+	}
+
+	start := event.StartTime.Format("3:04 PM")
+	switch {
+	case !event.EndTime.IsZero() && event.EndTime.Before(now):
+		return start + ", already over"
+	case event.StartTime.After(now):
+		return fmt.Sprintf("%s, starts in %d min", start, int(event.StartTime.Sub(now).Round(time.Minute).Minutes()))
+	case now.Sub(event.StartTime) < time.Minute:
+		return start + ", starting now"
+	default:
+		return fmt.Sprintf("%s, started %d min ago", start, int(now.Sub(event.StartTime).Minutes()))
+	}
+}
@@ -0,0 +1,76 @@
+package calendar
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/borgmon/focus-breaker/pkg/models"
+	"github.com/emersion/go-ical"
+)
+
+// parseAlarmMinutes returns how many minutes before the start of event each display
+// or audio VALARM of comp fires. Alarms that fire after the event started are ignored,
+// and an absolute trigger on a recurring event keeps its offset from the first instance.
+func parseAlarmMinutes(comp *ical.Component, event models.Event) []int {
+	if event.StartTime.IsZero() {
+		return nil
+	}
+
+	seen := make(map[int]bool)
+	minutes := []int{}
+
+	for _, alarm := range comp.Children {
+		if alarm.Name != ical.CompAlarm {
+			continue
+		}
+
+		// EMAIL alarms are for mail servers, not for the person at the computer
+		if actionProp := alarm.Props.Get(ical.PropAction); actionProp != nil {
+			action := strings.ToUpper(actionProp.Value)
+			if action != "DISPLAY" && action != "AUDIO" {
+				continue
+			}
+		}
+
+		triggerProp := alarm.Props.Get(ical.PropTrigger)
+		if triggerProp == nil {
+			continue
+		}
+
+		triggerTime, err := alarmTriggerTime(triggerProp, event)
+		if err != nil {
+			log.Printf("  [ALARM] Ignoring alarm on \"%s\" with invalid trigger %q: %v", event.Title, triggerProp.Value, err)
+			continue
+		}
+
+		before := int(event.StartTime.Sub(triggerTime).Round(time.Minute) / time.Minute)
+		if before < 0 || seen[before] {
+			continue
+		}
+		seen[before] = true
+		minutes = append(minutes, before)
+	}
+
+	sort.Ints(minutes)
+	return minutes
+}
+
+// alarmTriggerTime resolves an absolute or relative TRIGGER to the time it fires
+func alarmTriggerTime(prop *ical.Prop, event models.Event) (time.Time, error) {
+	if prop.ValueType() == ical.ValueDateTime {
+		return prop.DateTime(time.UTC)
+	}
+
+	offset, err := prop.Duration()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// Relative triggers fire around the start unless RELATED=END says otherwise
+	if strings.EqualFold(prop.Params.Get(ical.ParamRelated), "END") && !event.EndTime.IsZero() {
+		return event.EndTime.Add(offset), nil
+	}
+	return event.StartTime.Add(offset), nil
+}
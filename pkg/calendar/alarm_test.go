@@ -0,0 +1,70 @@
+package calendar
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-ical"
+)
+
+func TestParseAlarmMinutes(t *testing.T) {
+	// valarm is a display alarm with the given TRIGGER line
+	valarm := func(trigger string) string {
+		return "BEGIN:VALARM\nACTION:DISPLAY\nDESCRIPTION:Reminder\n" + trigger + "\nEND:VALARM"
+	}
+
+	tests := []struct {
+		name   string
+		alarms []string
+		want   []int
+	}{
+		{name: "no alarms", want: []int{}},
+		{name: "minutes before", alarms: []string{valarm("TRIGGER:-PT15M")}, want: []int{15}},
+		{name: "a day before", alarms: []string{valarm("TRIGGER:-P1D")}, want: []int{1440}},
+		{name: "at start", alarms: []string{valarm("TRIGGER:PT0S")}, want: []int{0}},
+		{name: "after start is ignored", alarms: []string{valarm("TRIGGER:PT5M")}, want: []int{}},
+		{name: "explicitly related to start", alarms: []string{valarm("TRIGGER;RELATED=START:-PT10M")}, want: []int{10}},
+		// The event runs 10:00-10:30
+		{name: "related to end, before start", alarms: []string{valarm("TRIGGER;RELATED=END:-PT45M")}, want: []int{15}},
+		{name: "related to end, during the event", alarms: []string{valarm("TRIGGER;RELATED=END:-PT10M")}, want: []int{}},
+		{name: "absolute", alarms: []string{valarm("TRIGGER;VALUE=DATE-TIME:20261016T094000Z")}, want: []int{20}},
+		{name: "absolute after start", alarms: []string{valarm("TRIGGER;VALUE=DATE-TIME:20261016T100500Z")}, want: []int{}},
+		{name: "rounded to the minute", alarms: []string{valarm("TRIGGER:-PT4M40S")}, want: []int{5}},
+		{name: "invalid trigger is ignored", alarms: []string{valarm("TRIGGER:soon"), valarm("TRIGGER:-PT5M")}, want: []int{5}},
+		{
+			name: "several alarms, sorted and without duplicates",
+			alarms: []string{
+				valarm("TRIGGER:-PT10M"),
+				valarm("TRIGGER:-PT1H"),
+				"BEGIN:VALARM\nACTION:AUDIO\nTRIGGER:-PT5M\nEND:VALARM",
+				valarm("TRIGGER;RELATED=END:-PT40M"),
+			},
+			want: []int{5, 10, 60},
+		},
+		{
+			name: "email alarms are for the mail server",
+			alarms: []string{
+				"BEGIN:VALARM\nACTION:EMAIL\nSUMMARY:Reminder\nDESCRIPTION:Reminder\nATTENDEE:mailto:me@example.com\nTRIGGER:-PT30M\nEND:VALARM",
+				valarm("TRIGGER:-PT2M"),
+			},
+			want: []int{2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed := icsFeed("BEGIN:VEVENT\nUID:review\nDTSTAMP:20261001T000000Z\nDTSTART:20261016T100000Z\nDTEND:20261016T103000Z\nSUMMARY:Review\n" +
+				strings.Join(tt.alarms, "\n") + "\nEND:VEVENT")
+			cal, err := ical.NewDecoder(strings.NewReader(feed)).Decode()
+			if err != nil {
+				t.Fatalf("decoding event: %v", err)
+			}
+
+			event := parseEvent(cal.Children[0])
+			if !slices.Equal(event.AlarmMinutes, tt.want) {
+				t.Errorf("AlarmMinutes = %v, want %v", event.AlarmMinutes, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package calendar
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/borgmon/focus-breaker/pkg/fsutil"
+	"github.com/borgmon/focus-breaker/pkg/models"
+)
+
+// CachedFeed is the last good calendar data of a source plus its HTTP validators
+type CachedFeed struct {
+	URL          string    `json:"url"`           // URL the body was fetched from
+	Body         string    `json:"-"`             // Raw iCalendar data, kept in a file of its own
+	ETag         string    `json:"etag"`          // ETag response header, if any
+	LastModified string    `json:"last_modified"` // Last-Modified response header, if any
+	FetchedAt    time.Time `json:"fetched_at"`    // When the server last confirmed this body
+}
+
+// FeedCache stores the last good feed of each source on disk: the body in an .ics
+// file and its validators in a small JSON file, so an unchanged feed only rewrites
+// the latter. It also keeps the events last parsed from each feed in memory.
+type FeedCache struct {
+	mu     sync.Mutex
+	dir    string
+	parsed map[string]*parsedFeed // key: source ID
+}
+
+// CacheFallbackError is returned alongside cached events when a fresh fetch failed
+type CacheFallbackError struct {
+	Err       error     // The error that prevented a fresh fetch
+	FetchedAt time.Time // When the cached copy was last fetched
+}
+
+func (e *CacheFallbackError) Error() string {
+	return fmt.Sprintf("using cached copy from %s: %v", e.FetchedAt.Format("2006-01-02 15:04"), e.Err)
+}
+
+func (e *CacheFallbackError) Unwrap() error {
+	return e.Err
+}
+
+// NewFeedCache creates a FeedCache that keeps its files in dir
+func NewFeedCache(dir string) *FeedCache {
+	return &FeedCache{dir: dir}
+}
+
+// path returns the metadata file for a source
+func (fc *FeedCache) path(sourceID string) string {
+	return filepath.Join(fc.dir, filepath.Base(sourceID)+".json")
+}
+
+// bodyPath returns the file holding the cached body of a source
+func (fc *FeedCache) bodyPath(sourceID string) string {
+	return filepath.Join(fc.dir, filepath.Base(sourceID)+".ics")
+}
+
+// Load returns the cached feed for a source, or nil if there is none for its current URL
+func (fc *FeedCache) Load(source models.ICalSource) *CachedFeed {
+	if fc == nil || source.ID == "" {
+		return nil
+	}
+
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	data, err := os.ReadFile(fc.path(source.ID))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: failed to read feed cache for '%s': %v", source.Name, err)
+		}
+		return nil
+	}
+
+	feed := &CachedFeed{}
+	if err := json.Unmarshal(data, feed); err != nil {
+		log.Printf("Warning: ignoring corrupt feed cache for '%s': %v", source.Name, err)
+		return nil
+	}
+
+	// The source was pointed somewhere else - the cached body no longer applies
+	if feed.URL != source.URL {
+		return nil
+	}
+
+	body, err := os.ReadFile(fc.bodyPath(source.ID))
+	if err != nil {
+		// Caches written before the body got its own file embed it
+		legacy := struct {
+			Body string `json:"body"`
+		}{}
+		if json.Unmarshal(data, &legacy) != nil || legacy.Body == "" {
+			return nil
+		}
+		body = []byte(legacy.Body)
+	}
+	feed.Body = string(body)
+
+	return feed
+}
+
+// Store writes the feed for a source, replacing the previous copy atomically. If
+// previous, the copy loaded before fetching, has the same body, only the validators
+// and fetch time are rewritten.
+func (fc *FeedCache) Store(source models.ICalSource, feed, previous *CachedFeed) {
+	if fc == nil || source.ID == "" || feed == nil {
+		return
+	}
+
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	if previous == nil || previous.Body != feed.Body {
+		if err := fsutil.WriteFileAtomic(fc.bodyPath(source.ID), []byte(feed.Body)); err != nil {
+			log.Printf("Warning: failed to write feed cache for '%s': %v", source.Name, err)
+			return
+		}
+	}
+
+	data, err := json.Marshal(feed)
+	if err != nil {
+		log.Printf("Warning: failed to encode feed cache for '%s': %v", source.Name, err)
+		return
+	}
+
+	if err := fsutil.WriteFileAtomic(fc.path(source.ID), data); err != nil {
+		log.Printf("Warning: failed to write feed cache for '%s': %v", source.Name, err)
+	}
+}
+
+// Prune removes cached feeds of sources that are no longer configured
+func (fc *FeedCache) Prune(sources []models.ICalSource) {
+	if fc == nil {
+		return
+	}
+
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	keep := make(map[string]bool)
+	configured := make(map[string]bool)
+	for _, source := range sources {
+		keep[filepath.Base(source.ID)+".json"] = true
+		keep[filepath.Base(source.ID)+".ics"] = true
+		configured[source.ID] = true
+	}
+
+	for sourceID := range fc.parsed {
+		if !configured[sourceID] {
+			delete(fc.parsed, sourceID)
+		}
+	}
+
+	entries, err := os.ReadDir(fc.dir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !(strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".ics")) || keep[name] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(fc.dir, name)); err == nil {
+			log.Printf("Removed feed cache of deleted source: %s", name)
+		}
+	}
+}
+
+// parseSlack is how far past the lookahead a feed is parsed, so later syncs of the
+// unchanged feed can reuse the events until the window moves past them
+const parseSlack = 24 * time.Hour
+
+// parsedFeed is the result of parsing a feed for a window ending at windowEnd
+type parsedFeed struct {
+	key       string // Body and settings the feed was parsed with, see parseKey
+	parsedAt  time.Time
+	windowEnd time.Time
+	result    *FetchResult
+}
+
+// parseKey identifies a feed body together with the source settings that decide how
+// it is parsed
+func parseKey(source models.ICalSource, body string, lookahead time.Duration, floatingTZID string) string {
+	return fmt.Sprintf("%x|%s|%s|%s|%+v", sha256.Sum256([]byte(body)), lookahead, floatingTZID, source.AllDayAlertTime, source.Filters)
+}
+
+// parse returns the events of body within lookahead from now, reusing the events
+// last parsed for the source if neither the body nor its settings have changed and
+// that parse still covers the window
+func (fc *FeedCache) parse(source models.ICalSource, body string, now time.Time, lookahead time.Duration, rules *sourceRules, allDay allDaySchedule, floatingTZID string) (*FetchResult, error) {
+	if fc == nil {
+		return parseICalData(body, now, lookahead, rules, allDay, floatingTZID)
+	}
+
+	key := parseKey(source, body, lookahead, floatingTZID)
+	windowEnd := now.Add(lookahead)
+
+	fc.mu.Lock()
+	parsed := fc.parsed[source.ID]
+	fc.mu.Unlock()
+
+	if parsed != nil && parsed.key == key && !now.Before(parsed.parsedAt) && !windowEnd.After(parsed.windowEnd) {
+		log.Printf("  [CACHE] '%s' unchanged, reusing events parsed at %s", source.Name, parsed.parsedAt.Format("2006-01-02 15:04"))
+		return parsed.result.within(now, windowEnd), nil
+	}
+
+	result, err := parseICalData(body, now, lookahead+parseSlack, rules, allDay, floatingTZID)
+	if err != nil {
+		return nil, err
+	}
+
+	fc.mu.Lock()
+	if fc.parsed == nil {
+		fc.parsed = make(map[string]*parsedFeed)
+	}
+	fc.parsed[source.ID] = &parsedFeed{key: key, parsedAt: now, windowEnd: now.Add(lookahead + parseSlack), result: result}
+	fc.mu.Unlock()
+
+	return result.within(now, windowEnd), nil
+}
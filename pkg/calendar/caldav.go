@@ -0,0 +1,300 @@
+package calendar
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/borgmon/focus-breaker/pkg/models"
+)
+
+// CalDAVCalendar describes a calendar collection found on a CalDAV server
+type CalDAVCalendar struct {
+	URL  string // Absolute URL of the calendar collection
+	Name string // Display name reported by the server
+}
+
+const propfindPrincipalBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:">
+  <d:prop>
+    <d:current-user-principal/>
+  </d:prop>
+</d:propfind>`
+
+const propfindHomeSetBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop>
+    <c:calendar-home-set/>
+  </d:prop>
+</d:propfind>`
+
+const propfindCalendarsBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop>
+    <d:displayname/>
+    <d:resourcetype/>
+    <c:supported-calendar-component-set/>
+  </d:prop>
+</d:propfind>`
+
+const calendarQueryBody = `<?xml version="1.0" encoding="utf-8"?>
+<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop>
+    <c:calendar-data/>
+  </d:prop>
+  <c:filter>
+    <c:comp-filter name="VCALENDAR">
+      <c:comp-filter name="VEVENT">
+        <c:time-range start="%s" end="%s"/>
+      </c:comp-filter>
+    </c:comp-filter>
+  </c:filter>
+</c:calendar-query>`
+
+// davMultistatus is the subset of a WebDAV multistatus response we care about
+type davMultistatus struct {
+	Responses []davResponse `xml:"DAV: response"`
+}
+
+type davResponse struct {
+	Href      string        `xml:"DAV: href"`
+	Propstats []davPropstat `xml:"DAV: propstat"`
+}
+
+type davPropstat struct {
+	Prop   davProp `xml:"DAV: prop"`
+	Status string  `xml:"DAV: status"`
+}
+
+type davProp struct {
+	CurrentUserPrincipal davHref         `xml:"DAV: current-user-principal"`
+	CalendarHomeSet      davHref         `xml:"urn:ietf:params:xml:ns:caldav calendar-home-set"`
+	DisplayName          string          `xml:"DAV: displayname"`
+	ResourceType         davResourceType `xml:"DAV: resourcetype"`
+	SupportedComponents  []davComp       `xml:"urn:ietf:params:xml:ns:caldav supported-calendar-component-set>comp"`
+	CalendarData         string          `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+}
+
+type davHref struct {
+	Href string `xml:"DAV: href"`
+}
+
+type davResourceType struct {
+	Calendar *struct{} `xml:"urn:ietf:params:xml:ns:caldav calendar"`
+}
+
+type davComp struct {
+	Name string `xml:"name,attr"`
+}
+
+// okProps returns the props of all propstats with a 2xx status
+func (r *davResponse) okProps() []davProp {
+	props := []davProp{}
+	for _, ps := range r.Propstats {
+		if ps.Status == "" || strings.Contains(ps.Status, " 2") {
+			props = append(props, ps.Prop)
+		}
+	}
+	return props
+}
+
+// DiscoverCalDAVCalendars finds the user's principal and calendar home on a CalDAV
+// server and returns every calendar collection that can hold events
+func DiscoverCalDAVCalendars(ctx context.Context, source models.ICalSource) ([]CalDAVCalendar, error) {
+	principalURL, err := findCalDAVPrincipal(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("CalDAV principal for '%s': %s", source.Name, principalURL)
+
+	homeURL, err := findCalDAVHome(ctx, source, principalURL)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("CalDAV calendar home for '%s': %s", source.Name, homeURL)
+
+	ms, err := davRequest(ctx, source, "PROPFIND", homeURL, "1", propfindCalendarsBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calendars: %w", err)
+	}
+
+	calendars := []CalDAVCalendar{}
+	for _, resp := range ms.Responses {
+		for _, prop := range resp.okProps() {
+			if prop.ResourceType.Calendar == nil || !supportsEvents(prop.SupportedComponents) {
+				continue
+			}
+
+			calendarURL, err := resolveHref(homeURL, resp.Href)
+			if err != nil {
+				continue
+			}
+
+			name := prop.DisplayName
+			if name == "" {
+				name = resp.Href
+			}
+			calendars = append(calendars, CalDAVCalendar{URL: calendarURL, Name: name})
+			break
+		}
+	}
+
+	return calendars, nil
+}
+
+// findCalDAVPrincipal looks up the current-user-principal, falling back to /.well-known/caldav
+func findCalDAVPrincipal(ctx context.Context, source models.ICalSource) (string, error) {
+	candidates := []string{source.URL}
+	if wellKnown, err := resolveHref(source.URL, "/.well-known/caldav"); err == nil && wellKnown != source.URL {
+		candidates = append(candidates, wellKnown)
+	}
+
+	var lastErr error
+	for _, candidate := range candidates {
+		ms, err := davRequest(ctx, source, "PROPFIND", candidate, "0", propfindPrincipalBody)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		for _, resp := range ms.Responses {
+			for _, prop := range resp.okProps() {
+				if prop.CurrentUserPrincipal.Href != "" {
+					return resolveHref(candidate, prop.CurrentUserPrincipal.Href)
+				}
+			}
+		}
+	}
+
+	if lastErr != nil {
+		return "", fmt.Errorf("failed to find CalDAV principal: %w", lastErr)
+	}
+	return "", fmt.Errorf("server did not report a current-user-principal - check the CalDAV URL")
+}
+
+// findCalDAVHome looks up the calendar-home-set of a principal
+func findCalDAVHome(ctx context.Context, source models.ICalSource, principalURL string) (string, error) {
+	ms, err := davRequest(ctx, source, "PROPFIND", principalURL, "0", propfindHomeSetBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to find calendar home: %w", err)
+	}
+
+	for _, resp := range ms.Responses {
+		for _, prop := range resp.okProps() {
+			if prop.CalendarHomeSet.Href != "" {
+				return resolveHref(principalURL, prop.CalendarHomeSet.Href)
+			}
+		}
+	}
+
+	return "", fmt.Errorf("server did not report a calendar-home-set for %s", principalURL)
+}
+
+// emptyCalendar stands in for a CalDAV query that matched no calendar objects
+const emptyCalendar = "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Focus Breaker//CalDAV//EN\r\nEND:VCALENDAR\r\n"
+
+// fetchCalDAVFeed runs a calendar-query REPORT against each selected calendar,
+// limited to the alert window, and joins the returned calendar data into one feed
+func fetchCalDAVFeed(ctx context.Context, source models.ICalSource, now time.Time, lookahead time.Duration) (*CachedFeed, error) {
+	calendarURLs := source.CalendarURLs
+	if len(calendarURLs) == 0 {
+		// No calendars picked - treat the source URL as the calendar collection itself
+		calendarURLs = []string{source.URL}
+	}
+
+	start := now.Add(-24 * time.Hour).UTC().Format("20060102T150405Z")
+	end := now.Add(lookahead).UTC().Format("20060102T150405Z")
+	body := fmt.Sprintf(calendarQueryBody, start, end)
+
+	calendarData := []string{}
+	for _, calendarURL := range calendarURLs {
+		ms, err := davRequest(ctx, source, "REPORT", calendarURL, "1", body)
+		if err != nil {
+			return nil, fmt.Errorf("calendar-query on %s failed: %w", calendarURL, err)
+		}
+
+		for _, resp := range ms.Responses {
+			for _, prop := range resp.okProps() {
+				if data := strings.TrimSpace(prop.CalendarData); data != "" {
+					calendarData = append(calendarData, data)
+				}
+			}
+		}
+	}
+
+	log.Printf("  [CALDAV] Received %d calendar object(s) from %d calendar(s)", len(calendarData), len(calendarURLs))
+
+	// Each calendar object is a full VCALENDAR; the decoder reads them back to back
+	feedBody := emptyCalendar
+	if len(calendarData) > 0 {
+		feedBody = strings.Join(calendarData, "\r\n")
+	}
+
+	return &CachedFeed{
+		URL:       source.URL,
+		Body:      feedBody,
+		FetchedAt: now,
+	}, nil
+}
+
+// davRequest sends a WebDAV request and decodes the multistatus response
+func davRequest(ctx context.Context, source models.ICalSource, method, target, depth, body string) (*davMultistatus, error) {
+	req, err := newSourceRequest(ctx, source, method, target, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	req.Header.Set("Depth", depth)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, transient(fmt.Errorf("HTTP request failed: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, fmt.Errorf("authentication failed (%s) - check the source's credentials and headers", resp.Status)
+	}
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, transientStatus(resp.StatusCode, fmt.Errorf("unexpected response %s from %s", resp.Status, target))
+	}
+
+	ms := &davMultistatus{}
+	if err := xml.NewDecoder(resp.Body).Decode(ms); err != nil {
+		return nil, fmt.Errorf("failed to decode multistatus response: %w", err)
+	}
+
+	return ms, nil
+}
+
+// resolveHref resolves a (possibly relative) href against the URL it was returned from
+func resolveHref(base, href string) (string, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return "", err
+	}
+	return baseURL.ResolveReference(ref).String(), nil
+}
+
+// supportsEvents reports whether a calendar accepts VEVENTs; servers that omit
+// supported-calendar-component-set allow all component types
+func supportsEvents(comps []davComp) bool {
+	if len(comps) == 0 {
+		return true
+	}
+	for _, comp := range comps {
+		if strings.EqualFold(comp.Name, "VEVENT") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package calendar
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/borgmon/focus-breaker/pkg/clock"
+	"github.com/borgmon/focus-breaker/pkg/models"
+)
+
+// multistatus wraps WebDAV responses in a multistatus document
+func multistatus(responses ...string) string {
+	return `<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">` + strings.Join(responses, "") + `</d:multistatus>`
+}
+
+// davProps is one response whose props were found
+func davProps(href, props string) string {
+	return `<d:response><d:href>` + href + `</d:href><d:propstat><d:prop>` + props +
+		`</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`
+}
+
+// fakeCalDAVServer serves a principal at /principals/alice/ with a calendar home at
+// /calendars/alice/. principalAt is the path answering current-user-principal, and
+// report answers calendar-query REPORTs. Requests without alice's password get a 401.
+func fakeCalDAVServer(t *testing.T, principalAt string, report func(path, body string) string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "alice" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+
+		var response string
+		switch {
+		case r.Method == "PROPFIND" && r.URL.Path == principalAt:
+			response = multistatus(davProps(r.URL.Path, `<d:current-user-principal><d:href>/principals/alice/</d:href></d:current-user-principal>`))
+		case r.Method == "PROPFIND" && r.URL.Path == "/principals/alice/":
+			response = multistatus(davProps(r.URL.Path, `<c:calendar-home-set><d:href>/calendars/alice/</d:href></c:calendar-home-set>`))
+		case r.Method == "PROPFIND" && r.URL.Path == "/calendars/alice/":
+			if r.Header.Get("Depth") != "1" {
+				t.Errorf("calendar listing with Depth %q, want 1", r.Header.Get("Depth"))
+			}
+			response = multistatus(
+				// The home itself is a plain collection
+				davProps("/calendars/alice/", `<d:resourcetype><d:collection/></d:resourcetype>`),
+				davProps("/calendars/alice/work/", `<d:displayname>Calendar</d:displayname>
+					<d:resourcetype><d:collection/><c:calendar/></d:resourcetype>
+					<c:supported-calendar-component-set><c:comp name="VEVENT"/><c:comp name="VTODO"/></c:supported-calendar-component-set>`),
+				// Same name in another account, and no component set: everything allowed
+				davProps("personal/", `<d:displayname>Calendar</d:displayname>
+					<d:resourcetype><d:collection/><c:calendar/></d:resourcetype>`),
+				davProps("/calendars/alice/tasks/", `<d:displayname>Tasks</d:displayname>
+					<d:resourcetype><d:collection/><c:calendar/></d:resourcetype>
+					<c:supported-calendar-component-set><c:comp name="VTODO"/></c:supported-calendar-component-set>`),
+				`<d:response><d:href>/calendars/alice/hidden/</d:href><d:propstat><d:prop>
+					<d:resourcetype><d:collection/><c:calendar/></d:resourcetype>
+				</d:prop><d:status>HTTP/1.1 403 Forbidden</d:status></d:propstat></d:response>`,
+			)
+		case r.Method == "REPORT" && report != nil:
+			response = report(r.URL.Path, string(body))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDiscoverCalDAVCalendars(t *testing.T) {
+	tests := []struct {
+		name        string
+		principalAt string // Path answering current-user-principal
+		sourcePath  string // Path of the URL the user entered
+		password    string
+		wantErr     string
+	}{
+		{name: "principal at the entered URL", principalAt: "/dav/", sourcePath: "/dav/", password: "secret"},
+		{name: "principal through .well-known", principalAt: "/.well-known/caldav", sourcePath: "/nothing-here/", password: "secret"},
+		{name: "wrong password", principalAt: "/dav/", sourcePath: "/dav/", password: "wrong", wantErr: "authentication failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := fakeCalDAVServer(t, tt.principalAt, nil)
+			source := models.ICalSource{Name: "Work", URL: server.URL + tt.sourcePath, Type: models.SourceTypeCalDAV, Username: "alice", Password: tt.password}
+
+			calendars, err := DiscoverCalDAVCalendars(context.Background(), source)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("DiscoverCalDAVCalendars() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DiscoverCalDAVCalendars() error: %v", err)
+			}
+
+			want := []CalDAVCalendar{
+				{URL: server.URL + "/calendars/alice/work/", Name: "Calendar"},
+				{URL: server.URL + "/calendars/alice/personal/", Name: "Calendar"},
+			}
+			if len(calendars) != len(want) {
+				t.Fatalf("found %+v, want %+v", calendars, want)
+			}
+			for i := range want {
+				if calendars[i] != want[i] {
+					t.Errorf("calendar %d = %+v, want %+v", i, calendars[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFetchEventsFromCalDAV(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	event := func(uid, start, end string) string {
+		return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//test//EN\r\nBEGIN:VEVENT\r\nUID:" + uid +
+			"\r\nDTSTAMP:20261001T000000Z\r\nDTSTART:" + start + "\r\nDTEND:" + end + "\r\nSUMMARY:" + uid +
+			"\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	}
+
+	queried := []string{}
+	server := fakeCalDAVServer(t, "/dav/", func(path, body string) string {
+		queried = append(queried, path)
+		if !strings.Contains(body, `start="20261015T090000Z" end="20261017T090000Z"`) {
+			t.Errorf("calendar-query on %s without the alert window: %s", path, body)
+		}
+		switch path {
+		case "/calendars/alice/work/":
+			return multistatus(
+				davProps("/calendars/alice/work/standup.ics", `<c:calendar-data>`+event("standup", "20261016T100000Z", "20261016T101500Z")+`</c:calendar-data>`),
+				davProps("/calendars/alice/work/review.ics", `<c:calendar-data>`+event("review", "20261016T140000Z", "20261016T150000Z")+`</c:calendar-data>`),
+			)
+		default:
+			return multistatus()
+		}
+	})
+
+	source := models.ICalSource{
+		Name:         "Work",
+		URL:          server.URL + "/dav/",
+		Type:         models.SourceTypeCalDAV,
+		Username:     "alice",
+		Password:     "secret",
+		CalendarURLs: []string{server.URL + "/calendars/alice/work/", server.URL + "/calendars/alice/personal/"},
+	}
+	result, err := FetchEvents(context.Background(), clock.NewSimulated(now), source, nil, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("FetchEvents() error: %v", err)
+	}
+	if len(queried) != 2 {
+		t.Errorf("queried %v, want both selected calendars", queried)
+	}
+	if result.FeedEvents != -1 {
+		t.Errorf("FeedEvents = %d, want -1 for a feed holding only the alert window", result.FeedEvents)
+	}
+	titles := []string{}
+	for _, event := range result.Events {
+		titles = append(titles, event.Title)
+	}
+	if len(titles) != 2 || !strings.Contains(strings.Join(titles, ","), "standup") || !strings.Contains(strings.Join(titles, ","), "review") {
+		t.Errorf("events %v, want standup and review", titles)
+	}
+}
@@ -0,0 +1,141 @@
+package calendar
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/borgmon/focus-breaker/pkg/models"
+	"github.com/emersion/go-ical"
+)
+
+// phonePattern matches an international dial-in number, optionally followed by the
+// one-tap suffix that dials the meeting ID and passcode (",,123456789#,,,,*654321#")
+var phonePattern = regexp.MustCompile(`\+\d[\d ().\-]{6,20}\d(?:,+[\d*#]+)*`)
+
+// meetingIDPattern matches the meeting ID Zoom, Teams, Webex and others print in the invitation
+var meetingIDPattern = regexp.MustCompile(`(?i)(?:meeting ID|meeting number|webinar ID|conference ID|access code)[^:\n]{0,20}:\s*(\d[\d \-]{4,}\d)`)
+
+// passcodePattern matches the passcode, password or PIN printed in the invitation: a
+// spaced number like "123 456 789#", or a token with a digit in it like "8xYz1Q", so
+// text like "Passcode: see below" is not taken for one
+var passcodePattern = regexp.MustCompile(`(?i)\b(?:passcode|password|pin)\s*:\s*(\d[\d ]*\d#?|[^\s,;]*\d[^\s,;]*)`)
+
+// zoomMeetingIDPattern pulls the meeting ID out of a Zoom join link
+var zoomMeetingIDPattern = regexp.MustCompile(`/(?:j|w)/(\d{9,11})`)
+
+// labelStopPattern ends a dial-in label before the credentials printed on the same line
+var labelStopPattern = regexp.MustCompile(`(?i)\b(?:passcode|password|pin|meeting ID|conference ID)\b|\+\d`)
+
+// textUnescaper undoes RFC 5545 TEXT escaping
+var textUnescaper = strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+
+// minDialDigits is the shortest number accepted as a dial-in, to skip dates and IDs
+const minDialDigits = 8
+
+// extractDialIns returns the phone dial-ins of an event: tel: CONFERENCE properties
+// first, then international numbers found in DESCRIPTION and LOCATION
+func extractDialIns(comp *ical.Component) []models.DialIn {
+	dialIns := []models.DialIn{}
+	seen := make(map[string]bool)
+
+	add := func(number, label string) {
+		number = normalizeDialNumber(number)
+		key := dialDigits(number)
+		if len(strings.TrimLeft(key, "+")) < minDialDigits || seen[key] {
+			return
+		}
+		seen[key] = true
+		dialIns = append(dialIns, models.DialIn{Number: number, Label: label})
+	}
+
+	for _, prop := range comp.Props.Values(ical.PropConference) {
+		value := strings.TrimSpace(prop.Value)
+		if !strings.HasPrefix(strings.ToLower(value), "tel:") {
+			continue
+		}
+		add(value[len("tel:"):], prop.Params.Get(ical.ParamLabel))
+	}
+
+	for _, name := range []string{ical.PropDescription, ical.PropLocation} {
+		prop := comp.Props.Get(name)
+		if prop == nil {
+			continue
+		}
+		for _, line := range strings.Split(textUnescaper.Replace(prop.Value), "\n") {
+			for _, loc := range phonePattern.FindAllStringIndex(line, -1) {
+				add(line[loc[0]:loc[1]], dialInLabel(line[:loc[0]], line[loc[1]:]))
+			}
+		}
+	}
+
+	return dialIns
+}
+
+// extractMeetingCredentials returns the meeting ID and passcode printed in the event
+// description, using the Zoom join link as a fallback for the meeting ID
+func extractMeetingCredentials(comp *ical.Component, conferences []models.Conference) (string, string) {
+	var meetingID, passcode string
+
+	if prop := comp.Props.Get(ical.PropDescription); prop != nil {
+		text := textUnescaper.Replace(prop.Value)
+		if match := meetingIDPattern.FindStringSubmatch(text); match != nil {
+			meetingID = strings.Join(strings.Fields(match[1]), " ")
+		}
+		if match := passcodePattern.FindStringSubmatch(text); match != nil {
+			passcode = strings.Join(strings.Fields(match[1]), " ")
+		}
+	}
+
+	if meetingID == "" {
+		for _, conference := range conferences {
+			if conference.Provider != "Zoom" {
+				continue
+			}
+			if match := zoomMeetingIDPattern.FindStringSubmatch(conference.URL); match != nil {
+				meetingID = match[1]
+				break
+			}
+		}
+	}
+
+	return meetingID, passcode
+}
+
+// dialInLabel describes a number from the text around it, e.g. "US (New York)"
+func dialInLabel(before, after string) string {
+	label := after
+	if loc := labelStopPattern.FindStringIndex(label); loc != nil {
+		label = label[:loc[0]]
+	}
+	label = strings.Trim(label, " \t-–—,:;")
+
+	// Google Meet puts the country in front: "(US) +1 234-555-0100 PIN: ..."
+	if label == "" {
+		label = strings.Trim(before, " \t-–—,:;")
+	}
+
+	if len(label) > 40 {
+		label = ""
+	}
+	return label
+}
+
+// normalizeDialNumber removes the formatting a phone can't dial but keeps the
+// pauses (",") and tones ("#", "*") of one-tap numbers
+func normalizeDialNumber(number string) string {
+	var sb strings.Builder
+	for _, r := range strings.TrimSpace(number) {
+		if (r >= '0' && r <= '9') || r == '+' || r == ',' || r == '#' || r == '*' {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// dialDigits returns the number part of a dial string without its one-tap suffix
+func dialDigits(number string) string {
+	if i := strings.IndexAny(number, ",#*"); i >= 0 {
+		return number[:i]
+	}
+	return number
+}
@@ -0,0 +1,88 @@
+package calendar
+
+import (
+	"testing"
+
+	"github.com/borgmon/focus-breaker/pkg/models"
+	"github.com/emersion/go-ical"
+)
+
+func TestExtractMeetingCredentials(t *testing.T) {
+	tests := []struct {
+		name          string
+		description   string
+		conferences   []models.Conference
+		wantMeetingID string
+		wantPasscode  string
+	}{
+		{
+			name: "Zoom",
+			description: "Alice is inviting you to a scheduled Zoom meeting.\n\n" +
+				"Join Zoom Meeting\nhttps://us02web.zoom.us/j/81234567890?pwd=bXlQd2Z4\n\n" +
+				"Meeting ID: 812 3456 7890\nPasscode: 482913\n\n---\n\n" +
+				"One tap mobile\n+16469313860,,81234567890#,,,,*482913# US\n+13017158592,,81234567890#,,,,*482913# US (Washington DC)",
+			wantMeetingID: "812 3456 7890",
+			wantPasscode:  "482913",
+		},
+		{
+			name:          "Zoom with a letter passcode",
+			description:   "Join Zoom Meeting\nhttps://zoom.us/j/81234567890\n\nMeeting ID: 812 3456 7890\nPasscode: 8xYz1Q\n",
+			wantMeetingID: "812 3456 7890",
+			wantPasscode:  "8xYz1Q",
+		},
+		{
+			name: "Microsoft Teams",
+			description: "________________________________________________________________________________\n" +
+				"Microsoft Teams meeting\nJoin on your computer, mobile app or room device\n" +
+				"Click here to join the meeting<https://teams.microsoft.com/l/meetup-join/19%3ameeting_abc%40thread.v2/0>\n" +
+				"Meeting ID: 245 678 901 234\nPasscode: Xk4pQ7\n" +
+				"Download Teams<https://www.microsoft.com/microsoft-teams/download-app> | Join on the web\n" +
+				"Or call in (audio only)\n+1 323-555-0166,,123456789#   United States, Los Angeles\n" +
+				"Phone Conference ID: 123 456 789#\n",
+			wantMeetingID: "245 678 901 234",
+			wantPasscode:  "Xk4pQ7",
+		},
+		{
+			name: "Google Meet",
+			description: "Join with Google Meet: https://meet.google.com/abc-defg-hij\n" +
+				"Or dial: (US) +1 347-555-0123 PIN: 123 456 789#\n" +
+				"More phone numbers: https://tel.meet/abc-defg-hij?pin=1234567890123\n",
+			wantPasscode: "123 456 789#",
+		},
+		{
+			name:          "passcode given later",
+			description:   "Passcode: see below\n\nJoin Zoom Meeting\nMeeting ID: 812 3456 7890\nPasscode: 482913",
+			wantMeetingID: "812 3456 7890",
+			wantPasscode:  "482913",
+		},
+		{
+			name:        "passcode given elsewhere",
+			description: "Password: see the chat\nPIN: ask Bob",
+		},
+		{
+			name:        "no passcode",
+			description: "Agenda: go through the Q3 roadmap.\nPlease read the doc beforehand.",
+		},
+		{
+			name:          "meeting ID from the Zoom link",
+			description:   "Dial in if you can't use video.",
+			conferences:   []models.Conference{{Provider: "Zoom", URL: "https://company.zoom.us/j/81234567890?pwd=abc"}},
+			wantMeetingID: "81234567890",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comp := ical.NewComponent(ical.CompEvent)
+			comp.Props.SetText(ical.PropDescription, tt.description)
+
+			meetingID, passcode := extractMeetingCredentials(comp, tt.conferences)
+			if meetingID != tt.wantMeetingID {
+				t.Errorf("meeting ID = %q, want %q", meetingID, tt.wantMeetingID)
+			}
+			if passcode != tt.wantPasscode {
+				t.Errorf("passcode = %q, want %q", passcode, tt.wantPasscode)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package calendar
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -8,49 +10,214 @@ import (
 	"strings"
 	"time"
 
+	"github.com/borgmon/focus-breaker/pkg/clock"
 	"github.com/borgmon/focus-breaker/pkg/models"
 	"github.com/emersion/go-ical"
 )
 
-// FetchEvents fetches and parses events from an iCal source
-func FetchEvents(source models.ICalSource) ([]models.Event, error) {
-	events, err := fetchAndParseICal(source.URL)
+// FetchResult is the outcome of fetching one calendar source
+type FetchResult struct {
+	Events     []models.Event         // Events within the alert window
+	Filtered   []models.FilteredEvent // Events within the alert window that were dropped, with the reason
+	FeedEvents int                    // VEVENTs in the feed before any filtering, -1 if the feed only holds the alert window
+}
+
+// FetchEvents fetches and parses events from an iCal source. The last good feed is
+// kept in cache (which may be nil); if a fresh fetch fails, events from the cached
+// copy are returned together with a *CacheFallbackError. Cancelling ctx aborts the
+// fetch without falling back to the cache. Only events starting within lookahead
+// from the clock's now and passing the source's filter rules are returned.
+func FetchEvents(ctx context.Context, clk clock.Clock, source models.ICalSource, cache *FeedCache, lookahead time.Duration) (*FetchResult, error) {
+	rules, err := newSourceRules(source.Filters)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid filter rules for '%s': %w", source.Name, err)
+	}
+	allDay, err := parseAllDayAlertTime(source.AllDayAlertTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid settings for '%s': %w", source.Name, err)
+	}
+
+	// Zone the feed's floating times are read in, empty for local time
+	floatingTZID := ""
+	if source.Timezone != "" {
+		ianaName, ok := ianaTimezone(source.Timezone)
+		if !ok {
+			return nil, fmt.Errorf("invalid time zone for '%s': unknown time zone %q", source.Name, source.Timezone)
+		}
+		floatingTZID = ianaName
+	}
+
+	cached := cache.Load(source)
+	now := clk.Now()
+
+	var feed *CachedFeed
+	if source.IsLocal() {
+		feed, err = readLocalFeed(ctx, source, now)
+	} else if source.IsCalDAV() {
+		feed, err = fetchCalDAVFeed(ctx, source, now, lookahead)
+	} else {
+		feed, err = fetchICalFeed(ctx, source, cached, now)
+	}
+
+	var result *FetchResult
+	if err == nil {
+		result, err = cache.parse(source, feed.Body, now, lookahead, rules, allDay, floatingTZID)
 	}
 
-	// Set the source ID for all events
+	if err != nil {
+		if cached == nil || errors.Is(ctx.Err(), context.Canceled) {
+			return nil, err
+		}
+
+		log.Printf("Fetching '%s' failed, falling back to cached copy from %s: %v",
+			source.Name, cached.FetchedAt.Format("2006-01-02 15:04"), err)
+		cachedResult, parseErr := cache.parse(source, cached.Body, now, lookahead, rules, allDay, floatingTZID)
+		if parseErr != nil {
+			return nil, err
+		}
+		cachedResult.assignSource(source)
+		cachedResult.countFeedEvents(source)
+		return cachedResult, &CacheFallbackError{Err: err, FetchedAt: cached.FetchedAt}
+	}
+
+	cache.Store(source, feed, cached)
+
+	result.assignSource(source)
+	result.countFeedEvents(source)
+	return result, nil
+}
+
+// countFeedEvents marks the feed size unknown for CalDAV sources: their feed holds
+// only the events of the alert window, so an empty one may just be a quiet weekend
+func (r *FetchResult) countFeedEvents(source models.ICalSource) {
+	if source.IsCalDAV() {
+		r.FeedEvents = -1
+	}
+}
+
+// within returns the events of r, kept and filtered, that overlap now..windowEnd
+func (r *FetchResult) within(now, windowEnd time.Time) *FetchResult {
+	inWindow := func(event models.Event) bool {
+		return event.StartTime.Before(windowEnd) && event.EndTime.After(now)
+	}
+
+	result := &FetchResult{Events: []models.Event{}, FeedEvents: r.FeedEvents}
+	for _, event := range r.Events {
+		if inWindow(event) {
+			result.Events = append(result.Events, event)
+		}
+	}
+	for _, filtered := range r.Filtered {
+		if inWindow(filtered.Event) {
+			result.Filtered = append(result.Filtered, filtered)
+		}
+	}
+	return result
+}
+
+// assignSource tags kept and filtered events with their source and fills in IDs for
+// events without a UID
+func (r *FetchResult) assignSource(source models.ICalSource) {
 	eventsWithoutUID := 0
-	for i := range events {
-		events[i].SourceID = source.ID
+	assign := func(event *models.Event) {
+		event.SourceID = source.ID
+		event.MyStatus = myStatus(source, *event)
 		// Fallback: if no iCal UID, use deterministic ID based on start time and title
-		if events[i].ID == "" {
-			events[i].ID = source.ID + "-" + events[i].StartTime.Format(time.RFC3339) + "-" + events[i].Title
+		if event.ID == "" {
+			event.ID = source.ID + "-" + event.StartTime.Format(time.RFC3339) + "-" + event.Title
 			eventsWithoutUID++
 		}
 	}
 
+	for i := range r.Events {
+		assign(&r.Events[i])
+	}
+	for i := range r.Filtered {
+		assign(&r.Filtered[i].Event)
+	}
+
 	if eventsWithoutUID > 0 {
 		log.Printf("Generated fallback IDs for %d events without UID", eventsWithoutUID)
 	}
+}
+
+// myStatus returns the user's PARTSTAT on an event, found through the source's
+// MyEmails. Organizers who are not listed as attendees count as accepted.
+func myStatus(source models.ICalSource, event models.Event) models.PartStat {
+	if len(source.MyEmails) == 0 {
+		return ""
+	}
 
-	return events, nil
+	for _, attendee := range event.Attendees {
+		if source.IsMyEmail(attendee.Email) {
+			return attendee.PartStat
+		}
+	}
+	if event.Organizer.Email != "" && source.IsMyEmail(event.Organizer.Email) {
+		return models.PartStatAccepted
+	}
+	return ""
 }
 
-func fetchAndParseICal(icalURL string) ([]models.Event, error) {
-	resp, err := http.Get(icalURL)
+// fetchICalFeed downloads an iCal feed, sending the cached validators so that an
+// unchanged feed is answered with 304 Not Modified and served from the cache
+func fetchICalFeed(ctx context.Context, source models.ICalSource, cached *CachedFeed, now time.Time) (*CachedFeed, error) {
+	req, err := newSourceRequest(ctx, source, http.MethodGet, source.URL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("HTTP request failed: %w", err)
+		return nil, err
+	}
+
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, transient(fmt.Errorf("HTTP request failed: %w", err))
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		log.Printf("  [CACHE] '%s' not modified since %s", source.Name, cached.FetchedAt.Format("2006-01-02 15:04"))
+		return &CachedFeed{
+			URL:          cached.URL,
+			Body:         cached.Body,
+			ETag:         cached.ETag,
+			LastModified: cached.LastModified,
+			FetchedAt:    now,
+		}, nil
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, fmt.Errorf("authentication failed (%s) - check the source's credentials and headers", resp.Status)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, transientStatus(resp.StatusCode, fmt.Errorf("unexpected response %s", resp.Status))
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, transient(fmt.Errorf("failed to read response body: %w", err))
 	}
 
-	bodyStr := string(body)
+	return &CachedFeed{
+		URL:          source.URL,
+		Body:         string(body),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    now,
+	}, nil
+}
 
+// parseICalData decodes an iCalendar document and returns the events starting
+// within lookahead from now that pass rules (which may be nil). Floating times are
+// read in the IANA zone floatingTZID, or in local time if it is empty.
+func parseICalData(bodyStr string, now time.Time, lookahead time.Duration, rules *sourceRules, allDay allDaySchedule, floatingTZID string) (*FetchResult, error) {
 	// Validate response format
 	if err := validateICalFormat(bodyStr); err != nil {
 		return nil, err
@@ -61,12 +228,16 @@ func fetchAndParseICal(icalURL string) ([]models.Event, error) {
 	seenEventIDs := make(map[string]bool)
 	seenEventKeys := make(map[string]bool) // key: title + start time
 
-	now := time.Now()
-	tomorrow := now.Add(24 * time.Hour)
+	windowEnd := now.Add(lookahead)
 
 	// Tracking filtered events
-	stats := &filterStats{}
+	stats := &filterStats{filteredByRule: make(map[string]int)}
 
+	// Collect all VEVENTs first - an override for one instance of a recurring
+	// event can appear anywhere in the feed, even in a later calendar, and so can
+	// the VTIMEZONE an event refers to
+	comps := []*ical.Component{}
+	timezoneComps := []*ical.Component{}
 	for {
 		cal, err := decoder.Decode()
 		if err == io.EOF {
@@ -80,80 +251,160 @@ func fetchAndParseICal(icalURL string) ([]models.Event, error) {
 
 		for _, comp := range cal.Children {
 			stats.totalComponents++
+			if comp.Name == ical.CompTimezone {
+				timezoneComps = append(timezoneComps, comp)
+				continue
+			}
 			if comp.Name != ical.CompEvent {
 				log.Printf("  [DEBUG] Skipping non-event component: %s", comp.Name)
 				continue
 			}
 			stats.totalEvents++
+			comps = append(comps, comp)
+		}
+	}
 
-			// Normalize Windows timezones to IANA timezones before parsing
-			normalizeComponentTimezones(comp)
-
-			event := parseEvent(comp)
+	// Normalize Windows, feed-defined and floating timezones before parsing
+	zones := newFeedTimezones(timezoneComps, now)
+	for _, comp := range comps {
+		normalizeComponentTimezones(comp, zones, floatingTZID)
+	}
 
-			// Check if this is a recurring event using go-ical's RecurrenceSet
-			if rruleProp := comp.Props.Get(ical.PropRecurrenceRule); rruleProp != nil {
-				log.Printf("  [RECURRING] Event: \"%s\" has RRULE: %s", event.Title, rruleProp.Value)
+	overrides := newRecurrenceOverrides(comps)
+	durations := make(map[string]time.Duration)    // key: UID of a recurring event
+	seriesZones := make(map[string]*time.Location) // key: UID, the zone its occurrence IDs are written in
 
-				// Use go-ical's built-in RecurrenceSet which handles RRULE, EXDATE, RDATE properly
-				// Get the timezone from the component
-				loc := getTimezoneFromComponent(comp)
+	for _, comp := range comps {
+		// Overrides are applied to the occurrences of their recurring event below
+		if isRecurrenceOverride(comp) {
+			continue
+		}
 
-				recurrenceSet, err := comp.RecurrenceSet(loc)
-				if err != nil {
-					log.Printf("  [RECURRING] Error parsing recurrence set for \"%s\": %v", event.Title, err)
-					// Fall back to treating as single event
-					if shouldIncludeEvent(event, now, tomorrow, stats) {
-						if !isDuplicate(event, seenEventIDs, seenEventKeys, stats) {
-							events = append(events, event)
-						}
-					}
-					continue
-				}
+		event := parseEvent(comp)
 
-				// Generate occurrences between now and tomorrow
-				duration := event.EndTime.Sub(event.StartTime)
-				occurrences := recurrenceSet.Between(now.Add(-24*time.Hour), tomorrow, true)
+		// Check if this is a recurring event using go-ical's RecurrenceSet
+		if rruleProp := comp.Props.Get(ical.PropRecurrenceRule); rruleProp != nil {
+			log.Printf("  [RECURRING] Event: \"%s\" has RRULE: %s", event.Title, rruleProp.Value)
 
-				log.Printf("  [RECURRING] Generated %d occurrence(s) for \"%s\"", len(occurrences), event.Title)
+			// Use go-ical's built-in RecurrenceSet which handles RRULE, EXDATE, RDATE properly
+			// Get the timezone from the component
+			loc := getTimezoneFromComponent(comp)
 
-				for _, occurrence := range occurrences {
-					recEvent := event
-					recEvent.StartTime = occurrence
-					recEvent.EndTime = occurrence.Add(duration)
-					recEvent.ID = event.ID + "-" + occurrence.Format(time.RFC3339)
+			recurrenceSet, err := comp.RecurrenceSet(loc)
+			if err != nil {
+				log.Printf("  [RECURRING] Error parsing recurrence set for \"%s\": %v", event.Title, err)
+				// Fall back to treating as single event
+				if shouldIncludeEvent(event, now, windowEnd, rules, allDay, stats) {
+					if !isDuplicate(event, seenEventIDs, seenEventKeys, stats) {
+						events = append(events, event)
+					}
+				}
+				continue
+			}
 
+			// Generate occurrences up to the end of the window, starting a day back
+			// so occurrences that are still in progress are kept
+			duration := event.EndTime.Sub(event.StartTime)
+			durations[event.ID] = duration
+			seriesZones[event.ID] = loc
+			occurrences := recurrenceSet.Between(now.Add(-24*time.Hour), windowEnd, true)
+
+			log.Printf("  [RECURRING] Generated %d occurrence(s) for \"%s\"", len(occurrences), event.Title)
+
+			for _, occurrence := range occurrences {
+				recEvent := event
+				recEvent.StartTime = occurrence
+				recEvent.EndTime = occurrence.Add(duration)
+
+				// A moved, edited or cancelled instance replaces the generated one
+				if override := overrides.take(event.ID, occurrence); override != nil {
+					recEvent = parseOverride(override, duration)
+					stats.overridesApplied++
+					log.Printf("  [OVERRIDE] Instance at %s replaced by \"%s\" at %s (Status: %s)",
+						occurrence.Format("2006-01-02 15:04"), recEvent.Title,
+						recEvent.StartTime.Format("2006-01-02 15:04"), recEvent.Status)
+				} else {
 					log.Printf("  [RECURRING] Instance at %s", occurrence.Format("2006-01-02 15:04"))
+				}
+				recEvent.ID = occurrenceID(event.ID, occurrence)
 
-					if shouldIncludeEvent(recEvent, now, tomorrow, stats) {
-						if !isDuplicate(recEvent, seenEventIDs, seenEventKeys, stats) {
-							events = append(events, recEvent)
-						}
+				if shouldIncludeEvent(recEvent, now, windowEnd, rules, allDay, stats) {
+					if !isDuplicate(recEvent, seenEventIDs, seenEventKeys, stats) {
+						events = append(events, recEvent)
 					}
 				}
-				continue
 			}
+			continue
+		}
+
+		// Process single event
+		if shouldIncludeEvent(event, now, windowEnd, rules, allDay, stats) {
+			if !isDuplicate(event, seenEventIDs, seenEventKeys, stats) {
+				events = append(events, event)
+			}
+		}
+	}
 
-			// Process single event
-			if shouldIncludeEvent(event, now, tomorrow, stats) {
-				if !isDuplicate(event, seenEventIDs, seenEventKeys, stats) {
-					events = append(events, event)
-				}
+	// Overrides whose original instance was not expanded, e.g. a meeting moved into
+	// the window from outside it, still stand on their own
+	for _, comp := range overrides.unused() {
+		uid, recurrenceID, _ := overrideInstance(comp)
+		event := parseOverride(comp, durations[uid])
+
+		// Same ID as the expanded occurrence gets once it is inside the window, even if
+		// the override is written in another zone than its series
+		loc, ok := seriesZones[uid]
+		if !ok {
+			loc = getTimezoneFromComponent(comp)
+		}
+		event.ID = occurrenceID(uid, recurrenceID.In(loc))
+		stats.overridesApplied++
+		log.Printf("  [OVERRIDE] Standalone instance \"%s\" originally at %s",
+			event.Title, recurrenceID.Format("2006-01-02 15:04"))
+
+		if shouldIncludeEvent(event, now, windowEnd, rules, allDay, stats) {
+			if !isDuplicate(event, seenEventIDs, seenEventKeys, stats) {
+				events = append(events, event)
 			}
 		}
 	}
 
+	for i := range events {
+		allDay.apply(&events[i])
+	}
+
 	// Log filtering summary
 	stats.logSummary(len(events))
 
-	return events, nil
+	return &FetchResult{Events: events, Filtered: stats.filtered, FeedEvents: stats.totalEvents}, nil
+}
+
+// newSourceRequest builds an HTTP request carrying the source's credentials and custom headers
+func newSourceRequest(ctx context.Context, source models.ICalSource, method, target string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	for name, value := range source.Headers {
+		req.Header.Set(name, value)
+	}
+
+	// Explicit credentials take precedence over an Authorization custom header
+	if source.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+source.BearerToken)
+	} else if source.Username != "" || source.Password != "" {
+		req.SetBasicAuth(source.Username, source.Password)
+	}
+
+	return req, nil
 }
 
 func validateICalFormat(bodyStr string) error {
 	// Check if response is HTML instead of iCalendar
 	upperBody := strings.ToUpper(strings.TrimSpace(bodyStr))
 	if strings.HasPrefix(upperBody, "<!DOCTYPE") || strings.HasPrefix(upperBody, "<HTML") {
-		return fmt.Errorf("received HTML instead of iCalendar data - check if URL requires authentication")
+		return fmt.Errorf("received HTML instead of iCalendar data - check if URL requires authentication or custom headers")
 	}
 
 	// Check if it starts with BEGIN:VCALENDAR
@@ -173,6 +424,7 @@ func isDuplicate(event models.Event, seenEventIDs, seenEventKeys map[string]bool
 	// Check for duplicates by ID
 	if seenEventIDs[event.ID] {
 		stats.filteredDuplicates++
+		stats.record(event, "Duplicate of another event with the same UID")
 		log.Printf("  [FILTERED] Duplicate (ID) - Event: \"%s\" (ID: %s)", event.Title, event.ID)
 		return true
 	}
@@ -181,6 +433,7 @@ func isDuplicate(event models.Event, seenEventIDs, seenEventKeys map[string]bool
 	eventKey := event.Title + "|" + event.StartTime.Format(time.RFC3339)
 	if seenEventKeys[eventKey] {
 		stats.filteredDuplicates++
+		stats.record(event, "Duplicate of another event with the same title and start")
 		log.Printf("  [FILTERED] Duplicate (Title+Time) - Event: \"%s\" (Start: %s)",
 			event.Title, event.StartTime.Format("2006-01-02 15:04"))
 		return true
@@ -199,14 +452,31 @@ type filterStats struct {
 	filteredAllDay        int
 	filteredOutsideWindow int
 	filteredDuplicates    int
+	filteredByRule        map[string]int // key: rule name
+	overridesApplied      int
+
+	filtered []models.FilteredEvent // Dropped events within the window, for the Events tab
+}
+
+// record keeps a dropped event and the reason for it
+func (s *filterStats) record(event models.Event, reason string) {
+	s.filtered = append(s.filtered, models.FilteredEvent{Event: event, Reason: reason})
 }
 
 func (s *filterStats) logSummary(includedCount int) {
-	totalFiltered := s.filteredMissingTime + s.filteredCancelled + s.filteredAllDay + s.filteredOutsideWindow + s.filteredDuplicates
-	log.Printf("  [SUMMARY] Total components: %d, Events: %d, Included: %d, Filtered: %d",
-		s.totalComponents, s.totalEvents, includedCount, totalFiltered)
+	filteredByRules := 0
+	for _, count := range s.filteredByRule {
+		filteredByRules += count
+	}
+
+	totalFiltered := s.filteredMissingTime + s.filteredCancelled + s.filteredAllDay + s.filteredOutsideWindow + s.filteredDuplicates + filteredByRules
+	log.Printf("  [SUMMARY] Total components: %d, Events: %d, Included: %d, Filtered: %d, Overrides: %d",
+		s.totalComponents, s.totalEvents, includedCount, totalFiltered, s.overridesApplied)
 	if totalFiltered > 0 {
-		log.Printf("  Filtered breakdown: %d cancelled, %d all-day, %d outside window, %d missing time, %d duplicates",
-			s.filteredCancelled, s.filteredAllDay, s.filteredOutsideWindow, s.filteredMissingTime, s.filteredDuplicates)
+		log.Printf("  Filtered breakdown: %d cancelled, %d all-day, %d outside window, %d missing time, %d duplicates, %d by rules",
+			s.filteredCancelled, s.filteredAllDay, s.filteredOutsideWindow, s.filteredMissingTime, s.filteredDuplicates, filteredByRules)
+	}
+	for rule, count := range s.filteredByRule {
+		log.Printf("  Filtered by rule \"%s\": %d", rule, count)
 	}
 }
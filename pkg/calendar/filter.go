@@ -7,7 +7,7 @@ import (
 	"github.com/borgmon/focus-breaker/pkg/models"
 )
 
-func shouldIncludeEvent(event models.Event, now, tomorrow time.Time, stats *filterStats) bool {
+func shouldIncludeEvent(event models.Event, now, windowEnd time.Time, rules *sourceRules, allDay allDaySchedule, stats *filterStats) bool {
 	// Filter events with missing time information
 	if event.StartTime.IsZero() || event.EndTime.IsZero() {
 		stats.filteredMissingTime++
@@ -16,44 +16,52 @@ func shouldIncludeEvent(event models.Event, now, tomorrow time.Time, stats *filt
 		return false
 	}
 
+	// Only dropped events the user could have expected an alert for are recorded
+	inWindow := event.StartTime.Before(windowEnd) && event.EndTime.After(now)
+
 	// Filter out cancelled events
 	if event.Status == "CANCELLED" {
 		stats.filteredCancelled++
+		if inWindow {
+			stats.record(event, "Cancelled")
+		}
 		log.Printf("  [FILTERED] [Cancelled] - Event: \"%s\" (Start: %s, Status: %s)",
 			event.Title, event.StartTime.Format("2006-01-02 15:04"), event.Status)
 		return false
 	}
 
-	// Filter out all-day events
-	if isAllDayEvent(event) {
+	// Filter out all-day events unless the source alerts them at a set time
+	if event.AllDay && !allDay.alerted() {
 		stats.filteredAllDay++
+		if inWindow {
+			stats.record(event, "All-day event")
+		}
 		log.Printf("  [FILTERED] [All-day] - Event: \"%s\" (Start: %s, End: %s, Duration: %v)",
 			event.Title, event.StartTime.Format("2006-01-02 15:04"),
 			event.EndTime.Format("2006-01-02 15:04"), event.EndTime.Sub(event.StartTime))
 		return false
 	}
 
-	// Include events within the time window (now to tomorrow)
-	if event.StartTime.Before(tomorrow) && event.EndTime.After(now) {
-		log.Printf("  [INCLUDED] Event: \"%s\" (Start: %s, End: %s)",
+	// Filter events outside the time window (now to the end of the lookahead)
+	if !inWindow {
+		stats.filteredOutsideWindow++
+		log.Printf("  [FILTERED] [Outside window] - Event: \"%s\" (Start: %s, End: %s, Now: %s, Window end: %s)",
 			event.Title, event.StartTime.Format("2006-01-02 15:04"),
-			event.EndTime.Format("2006-01-02 15:04"))
-		return true
+			event.EndTime.Format("2006-01-02 15:04"), now.Format("2006-01-02 15:04"), windowEnd.Format("2006-01-02 15:04"))
+		return false
 	}
 
-	// Filter events outside the time window
-	stats.filteredOutsideWindow++
-	log.Printf("  [FILTERED] [Outside window] - Event: \"%s\" (Start: %s, End: %s, Now: %s, Tomorrow: %s)",
-		event.Title, event.StartTime.Format("2006-01-02 15:04"),
-		event.EndTime.Format("2006-01-02 15:04"), now.Format("2006-01-02 15:04"), tomorrow.Format("2006-01-02 15:04"))
-	return false
-}
-
-func isAllDayEvent(event models.Event) bool {
-	startDate := event.StartTime.Format("2006-01-02")
-	endDate := event.EndTime.Format("2006-01-02")
-	duration := event.EndTime.Sub(event.StartTime)
+	// Filter events rejected by the source's rules
+	if rule := rules.rejectingRule(event); rule != "" {
+		stats.filteredByRule[rule]++
+		stats.record(event, "Filter rule: "+rule)
+		log.Printf("  [FILTERED] [Rule: %s] - Event: \"%s\" (Start: %s)",
+			rule, event.Title, event.StartTime.Format("2006-01-02 15:04"))
+		return false
+	}
 
-	// An event is considered all-day if it spans multiple days and is >= 24 hours
-	return startDate != endDate && duration >= 24*time.Hour
+	log.Printf("  [INCLUDED] Event: \"%s\" (Start: %s, End: %s)",
+		event.Title, event.StartTime.Format("2006-01-02 15:04"),
+		event.EndTime.Format("2006-01-02 15:04"))
+	return true
 }
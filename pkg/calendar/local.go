@@ -0,0 +1,109 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/borgmon/focus-breaker/pkg/models"
+)
+
+// LocalPath converts a file:// URL into a filesystem path
+func LocalPath(fileURL string) (string, error) {
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid file URL: %w", err)
+	}
+	if !strings.EqualFold(u.Scheme, "file") {
+		return "", fmt.Errorf("not a file:// URL: %s", fileURL)
+	}
+
+	path := u.Path
+	if u.Host != "" && u.Host != "localhost" {
+		// file://relative/path - treat host as the first path element
+		path = u.Host + path
+	}
+	// file:///C:/Users/... parses to /C:/Users/...
+	if runtime.GOOS == "windows" && len(path) >= 3 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	if path == "" {
+		return "", fmt.Errorf("file URL has no path: %s", fileURL)
+	}
+
+	return filepath.FromSlash(path), nil
+}
+
+// isICSFile reports whether a file name has the .ics extension
+func isICSFile(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".ics")
+}
+
+// readLocalFeed reads a single .ics file, or every .ics file in a directory, into one feed
+func readLocalFeed(ctx context.Context, source models.ICalSource, now time.Time) (*CachedFeed, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	path, err := LocalPath(source.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access %s: %w", path, err)
+	}
+
+	if !info.IsDir() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		return &CachedFeed{URL: source.URL, Body: string(data), FetchedAt: now}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", path, err)
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && isICSFile(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	calendarData := []string{}
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(path, name))
+		if err != nil {
+			log.Printf("  [LOCAL] Skipping unreadable file %s: %v", name, err)
+			continue
+		}
+		if err := validateICalFormat(string(data)); err != nil {
+			log.Printf("  [LOCAL] Skipping %s: %v", name, err)
+			continue
+		}
+		calendarData = append(calendarData, strings.TrimSpace(string(data)))
+	}
+
+	log.Printf("  [LOCAL] Read %d of %d .ics file(s) from %s", len(calendarData), len(names), path)
+
+	// Each file is a full VCALENDAR; the decoder reads them back to back
+	body := emptyCalendar
+	if len(calendarData) > 0 {
+		body = strings.Join(calendarData, "\r\n")
+	}
+
+	return &CachedFeed{URL: source.URL, Body: body, FetchedAt: now}, nil
+}
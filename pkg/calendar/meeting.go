@@ -0,0 +1,215 @@
+package calendar
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/borgmon/focus-breaker/pkg/models"
+	"github.com/emersion/go-ical"
+)
+
+// MeetingProvider recognizes the join links of one video conferencing service
+type MeetingProvider struct {
+	Name    string         // Label shown to the user, e.g. "Zoom"
+	Pattern *regexp.Regexp // Matches a complete join URL
+}
+
+// urlChars is the character class of a URL inside free text
+const urlChars = `[^\s<>"'{}|\\^` + "`" + `\[\]]`
+
+// defaultMeetingProviders are the built-in providers, checked after custom ones
+var defaultMeetingProviders = []MeetingProvider{
+	{"Zoom", regexp.MustCompile(`https?://(?:[\w-]+\.)*zoom(?:gov)?\.(?:us|com)/(?:j|my|w|s|wc)/` + urlChars + `+`)},
+	{"Google Meet", regexp.MustCompile(`https?://meet\.google\.com/` + urlChars + `+`)},
+	{"Microsoft Teams", regexp.MustCompile(`https?://teams\.(?:microsoft|live)\.com/(?:l/meetup-join|meet)/` + urlChars + `+`)},
+	{"Webex", regexp.MustCompile(`https?://[\w-]+\.webex\.com/` + urlChars + `+`)},
+	{"Jitsi", regexp.MustCompile(`https?://(?:meet\.jit\.si|8x8\.vc)/` + urlChars + `+`)},
+	{"Whereby", regexp.MustCompile(`https?://whereby\.com/` + urlChars + `+`)},
+	{"Amazon Chime", regexp.MustCompile(`https?://(?:chime\.aws/\d+|app\.chime\.aws/meetings/` + urlChars + `+)`)},
+	{"Slack Huddle", regexp.MustCompile(`https?://[\w-]+\.slack\.com/huddle/` + urlChars + `+`)},
+	{"GoTo Meeting", regexp.MustCompile(`https?://(?:global\.gotomeeting\.com/join|meet\.goto\.com)/` + urlChars + `+`)},
+}
+
+// anyURLPattern matches any web URL, used when no provider link is found
+var anyURLPattern = regexp.MustCompile(`https?://` + urlChars + `+`)
+
+// Vendor properties that carry the join link of a meeting
+const (
+	propGoogleConference = "X-GOOGLE-CONFERENCE"
+	propTeamsMeetingURL  = "X-MICROSOFT-SKYPETEAMSMEETINGURL"
+	propTeamsExternalURL = "X-MICROSOFT-ONLINEMEETINGEXTERNALLINK"
+)
+
+var (
+	customProvidersMu sync.RWMutex
+	customProviders   []MeetingProvider
+)
+
+// SetCustomMeetingProviders replaces the user-defined providers, which take precedence
+// over the built-in ones. An error is returned, and nothing changed, if a pattern
+// does not compile.
+func SetCustomMeetingProviders(patterns []models.MeetingProviderPattern) error {
+	providers := []MeetingProvider{}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern for meeting provider '%s': %w", pattern.Name, err)
+		}
+		providers = append(providers, MeetingProvider{Name: pattern.Name, Pattern: re})
+	}
+
+	customProvidersMu.Lock()
+	defer customProvidersMu.Unlock()
+	customProviders = providers
+	return nil
+}
+
+// meetingProviders returns custom providers followed by the built-in ones
+func meetingProviders() []MeetingProvider {
+	customProvidersMu.RLock()
+	defer customProvidersMu.RUnlock()
+
+	providers := make([]MeetingProvider, 0, len(customProviders)+len(defaultMeetingProviders))
+	providers = append(providers, customProviders...)
+	return append(providers, defaultMeetingProviders...)
+}
+
+// extractConferences returns every meeting link of an event, best first: RFC 7986
+// CONFERENCE properties, vendor properties, then provider links found in DESCRIPTION,
+// LOCATION and URL. If none of these yield a link, the first URL in the description or
+// location is returned as a generic link.
+func extractConferences(comp *ical.Component) []models.Conference {
+	providers := meetingProviders()
+	conferences := []models.Conference{}
+	seen := make(map[string]bool)
+
+	add := func(provider, link string) {
+		link = trimURL(link)
+		if link == "" || seen[link] {
+			return
+		}
+		seen[link] = true
+		conferences = append(conferences, models.Conference{Provider: provider, URL: link})
+	}
+
+	// RFC 7986 CONFERENCE; only links that can be opened, dial-ins are handled elsewhere
+	for _, prop := range comp.Props.Values(ical.PropConference) {
+		link := strings.TrimSpace(prop.Value)
+		if !isWebURL(link) {
+			continue
+		}
+		provider := identifyProvider(providers, link)
+		if provider == "" {
+			provider = prop.Params.Get(ical.ParamLabel)
+		}
+		if provider == "" {
+			provider = "Conference"
+		}
+		add(provider, link)
+	}
+
+	// Vendor properties from Google Calendar and Exchange
+	if prop := comp.Props.Get(propGoogleConference); prop != nil && isWebURL(prop.Value) {
+		add("Google Meet", prop.Value)
+	}
+	for _, name := range []string{propTeamsMeetingURL, propTeamsExternalURL} {
+		if prop := comp.Props.Get(name); prop != nil && isWebURL(prop.Value) {
+			add("Microsoft Teams", prop.Value)
+		}
+	}
+
+	// Provider links in free text
+	for _, name := range []string{ical.PropDescription, ical.PropLocation, ical.PropURL} {
+		prop := comp.Props.Get(name)
+		if prop == nil {
+			continue
+		}
+		for _, provider := range providers {
+			for _, match := range provider.Pattern.FindAllString(prop.Value, -1) {
+				add(provider.Name, match)
+			}
+		}
+	}
+
+	// Fall back to the first URL in the description or location
+	if len(conferences) == 0 {
+		for _, name := range []string{ical.PropDescription, ical.PropLocation} {
+			if prop := comp.Props.Get(name); prop != nil {
+				if match := anyURLPattern.FindString(prop.Value); match != "" {
+					add("Link", match)
+					break
+				}
+			}
+		}
+	}
+
+	return conferences
+}
+
+// identifyProvider returns the name of the first provider matching link, or ""
+func identifyProvider(providers []MeetingProvider, link string) string {
+	for _, provider := range providers {
+		if provider.Pattern.MatchString(link) {
+			return provider.Name
+		}
+	}
+	return ""
+}
+
+// isWebURL reports whether s is an http(s) URL
+func isWebURL(s string) bool {
+	lower := strings.ToLower(strings.TrimSpace(s))
+	return strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "http://")
+}
+
+// trimURL strips whitespace and punctuation that free text tends to leave at the end of a URL
+func trimURL(link string) string {
+	return strings.TrimRight(strings.TrimSpace(link), ".,;:!?)")
+}
+
+// nativeAppLinkBuilders turn a provider's https join link into the URL scheme its
+// desktop client registers, so the browser bounce page is skipped
+var nativeAppLinkBuilders = map[string]func(u *url.URL) (string, bool){
+	"Zoom": func(u *url.URL) (string, bool) {
+		match := zoomMeetingIDPattern.FindStringSubmatch(u.Path)
+		if match == nil {
+			return "", false
+		}
+		query := url.Values{"action": {"join"}, "confno": {match[1]}}
+		if pwd := u.Query().Get("pwd"); pwd != "" {
+			query.Set("pwd", pwd)
+		}
+		return "zoommtg://" + u.Host + "/join?" + query.Encode(), true
+	},
+	"Microsoft Teams": func(u *url.URL) (string, bool) {
+		if !strings.HasPrefix(u.Path, "/l/") {
+			return "", false
+		}
+		link := "msteams:" + u.EscapedPath()
+		if u.RawQuery != "" {
+			link += "?" + u.RawQuery
+		}
+		return link, true
+	},
+}
+
+// NativeAppProviders lists the providers whose links can be opened in their desktop app
+func NativeAppProviders() []string {
+	return []string{"Zoom", "Microsoft Teams"}
+}
+
+// NativeAppLink returns the desktop-app link for a conference, if its provider has one
+func NativeAppLink(conference models.Conference) (string, bool) {
+	build, exists := nativeAppLinkBuilders[conference.Provider]
+	if !exists {
+		return "", false
+	}
+	u, err := url.Parse(conference.URL)
+	if err != nil {
+		return "", false
+	}
+	return build(u)
+}
@@ -24,21 +24,42 @@ func parseEvent(comp *ical.Component) models.Event {
 
 	if descProp := comp.Props.Get(ical.PropDescription); descProp != nil {
 		event.Description = descProp.Value
-		event.MeetingLink = extractMeetingLink(descProp.Value)
 	}
 
+	// Zone of floating date-times, see normalizeComponentTimezones
+	loc := getTimezoneFromComponent(comp)
+
 	if startProp := comp.Props.Get(ical.PropDateTimeStart); startProp != nil {
-		if t, err := parseDateTimeProperty(startProp); err == nil {
+		if t, err := parseDateTimeProperty(startProp, loc); err == nil {
 			event.StartTime = t
 		}
+		event.AllDay = isDateValue(startProp)
+	}
+
+	// Remember the zone the event was written in, to show it next to local time.
+	// Only IANA zones are kept, so the name can be loaded again wherever it is shown.
+	if loc != time.Local && loc != time.UTC && !event.AllDay {
+		if _, err := time.LoadLocation(loc.String()); err == nil {
+			event.Timezone = loc.String()
+		}
+	}
+
+	// Exchange marks all-day events that it exports with midnight DATE-TIMEs
+	if allDayProp := comp.Props.Get(propMicrosoftAllDay); allDayProp != nil && strings.EqualFold(allDayProp.Value, "TRUE") {
+		event.AllDay = true
 	}
 
 	if endProp := comp.Props.Get(ical.PropDateTimeEnd); endProp != nil {
-		if t, err := parseDateTimeProperty(endProp); err == nil {
+		if t, err := parseDateTimeProperty(endProp, loc); err == nil {
 			event.EndTime = t
 		}
 	}
 
+	// An all-day event without DTEND lasts one day (RFC 5545 3.6.1)
+	if event.AllDay && event.EndTime.IsZero() && !event.StartTime.IsZero() {
+		event.EndTime = event.StartTime.AddDate(0, 0, 1)
+	}
+
 	if statusProp := comp.Props.Get(ical.PropStatus); statusProp != nil {
 		event.Status = statusProp.Value
 	}
@@ -48,17 +69,95 @@ func parseEvent(comp *ical.Component) models.Event {
 		event.Status = "CANCELLED"
 	}
 
-	// Try to extract meeting link from location if not found in description
-	if locProp := comp.Props.Get(ical.PropLocation); locProp != nil && event.MeetingLink == "" {
-		event.MeetingLink = extractMeetingLink(locProp.Value)
+	if locProp := comp.Props.Get(ical.PropLocation); locProp != nil {
+		event.Location = locProp.Value
+	}
+
+	// All meeting links; the best one is what "Join Meeting" opens
+	event.Conferences = extractConferences(comp)
+	if len(event.Conferences) > 0 {
+		event.MeetingLink = event.Conferences[0].URL
+	}
+
+	// Phone dial-ins, for when the link can't be opened
+	event.DialIns = extractDialIns(comp)
+	event.MeetingID, event.Passcode = extractMeetingCredentials(comp, event.Conferences)
+
+	for _, categoriesProp := range comp.Props.Values(ical.PropCategories) {
+		if categories, err := categoriesProp.TextList(); err == nil {
+			for _, category := range categories {
+				if category = strings.TrimSpace(category); category != "" {
+					event.Categories = append(event.Categories, category)
+				}
+			}
+		}
+	}
+
+	if urlProp := comp.Props.Get(ical.PropURL); urlProp != nil {
+		event.URL = strings.TrimSpace(urlProp.Value)
+	}
+
+	if transpProp := comp.Props.Get(ical.PropTransparency); transpProp != nil {
+		event.Transparency = strings.ToUpper(transpProp.Value)
+	}
+
+	if priorityProp := comp.Props.Get(ical.PropPriority); priorityProp != nil {
+		if priority, err := priorityProp.Int(); err == nil {
+			event.Priority = priority
+		}
+	}
+
+	if classProp := comp.Props.Get(ical.PropClass); classProp != nil {
+		event.Class = strings.ToUpper(classProp.Value)
+	}
+
+	// Reminders set in the calendar itself
+	event.AlarmMinutes = parseAlarmMinutes(comp, event)
+
+	// Participants, used to find the user's own response
+	if orgProp := comp.Props.Get(ical.PropOrganizer); orgProp != nil {
+		event.Organizer = parseAttendee(orgProp)
+	}
+	for _, attendeeProp := range comp.Props.Values(ical.PropAttendee) {
+		attendee := parseAttendee(&attendeeProp)
+		if attendee.PartStat == "" {
+			attendee.PartStat = models.PartStatNeedsAction // RFC 5545 default
+		}
+		event.Attendees = append(event.Attendees, attendee)
 	}
 
 	return event
 }
 
-func parseDateTimeProperty(prop *ical.Prop) (time.Time, error) {
-	// First try the standard DateTime method with local timezone
-	if t, err := prop.DateTime(time.Local); err == nil {
+// parseAttendee reads a calendar address property such as ATTENDEE or ORGANIZER
+func parseAttendee(prop *ical.Prop) models.Attendee {
+	email := strings.TrimSpace(prop.Value)
+	if len(email) >= 7 && strings.EqualFold(email[:7], "mailto:") {
+		email = email[7:]
+	}
+
+	return models.Attendee{
+		Email:    email,
+		Name:     prop.Params.Get(ical.ParamCommonName),
+		PartStat: models.PartStat(strings.ToUpper(prop.Params.Get(ical.ParamParticipationStatus))),
+		Role:     strings.ToUpper(prop.Params.Get(ical.ParamRole)),
+	}
+}
+
+// parseOverride parses a RECURRENCE-ID instance; without DTEND it keeps the duration
+// of the recurring event
+func parseOverride(comp *ical.Component, duration time.Duration) models.Event {
+	event := parseEvent(comp)
+	if event.EndTime.IsZero() && !event.StartTime.IsZero() && duration > 0 {
+		event.EndTime = event.StartTime.Add(duration)
+	}
+	return event
+}
+
+// parseDateTimeProperty parses a date-time, reading floating values in loc
+func parseDateTimeProperty(prop *ical.Prop, loc *time.Location) (time.Time, error) {
+	// First try the standard DateTime method, which honors TZID and UTC values
+	if t, err := prop.DateTime(loc); err == nil {
 		return t.In(time.Local), nil
 	}
 
@@ -74,36 +173,24 @@ func parseDateTimeProperty(prop *ical.Prop) (time.Time, error) {
 	}
 
 	for _, format := range formats {
-		if t, err := time.ParseInLocation(format, value, time.Local); err == nil {
-			return t, nil
+		if t, err := time.ParseInLocation(format, value, loc); err == nil {
+			return t.In(time.Local), nil
 		}
 	}
 
 	return time.Time{}, fmt.Errorf("unable to parse datetime value: %s", value)
 }
 
-func extractMeetingLink(text string) string {
-	urlRegex := regexp.MustCompile(`https?://[^\s<>"{}|\\^[\]` + "`" + `]+`)
-	matches := urlRegex.FindAllString(text, -1)
-
-	// Prioritize known meeting platforms
-	for _, match := range matches {
-		lower := strings.ToLower(match)
-		if strings.Contains(lower, "zoom") ||
-			strings.Contains(lower, "meet.google") ||
-			strings.Contains(lower, "teams.microsoft") ||
-			strings.Contains(lower, "webex") ||
-			strings.Contains(lower, "gotomeeting") {
-			return match
-		}
-	}
+// propMicrosoftAllDay is set to TRUE by Exchange and Outlook on all-day events
+const propMicrosoftAllDay = "X-MICROSOFT-CDO-ALLDAYEVENT"
 
-	// Return first URL if no known platform found
-	if len(matches) > 0 {
-		return matches[0]
+// isDateValue reports whether prop holds a DATE (VALUE=DATE, or a bare YYYYMMDD value)
+func isDateValue(prop *ical.Prop) bool {
+	if prop.Params.Get(ical.ParamValue) != "" {
+		return prop.ValueType() == ical.ValueDate
 	}
-
-	return ""
+	value := strings.TrimSpace(prop.Value)
+	return len(value) == len("20060102") && !strings.Contains(value, "T")
 }
 
 func isCancelledTitle(title string) bool {
@@ -0,0 +1,89 @@
+package calendar
+
+import (
+	"time"
+
+	"github.com/emersion/go-ical"
+)
+
+// recurrenceOverrides indexes VEVENTs carrying a RECURRENCE-ID, i.e. single instances
+// of a recurring event that were moved, edited or cancelled
+type recurrenceOverrides struct {
+	byInstance map[string]*ical.Component // key: UID + original start instant
+	order      []*ical.Component          // overrides in feed order
+	used       map[*ical.Component]bool   // overrides that replaced an expanded instance
+}
+
+// newRecurrenceOverrides collects the overrides among comps
+func newRecurrenceOverrides(comps []*ical.Component) *recurrenceOverrides {
+	ro := &recurrenceOverrides{
+		byInstance: make(map[string]*ical.Component),
+		used:       make(map[*ical.Component]bool),
+	}
+
+	for _, comp := range comps {
+		uid, recurrenceID, ok := overrideInstance(comp)
+		if !ok {
+			continue
+		}
+		ro.byInstance[instanceKey(uid, recurrenceID)] = comp
+		ro.order = append(ro.order, comp)
+	}
+
+	return ro
+}
+
+// take returns the override for the occurrence of uid starting at occurrence, if any,
+// and marks it as used
+func (ro *recurrenceOverrides) take(uid string, occurrence time.Time) *ical.Component {
+	comp, exists := ro.byInstance[instanceKey(uid, occurrence)]
+	if !exists {
+		return nil
+	}
+	ro.used[comp] = true
+	return comp
+}
+
+// unused returns overrides that did not match any expanded occurrence, e.g. because
+// the original instance lies outside the window but was moved into it
+func (ro *recurrenceOverrides) unused() []*ical.Component {
+	comps := []*ical.Component{}
+	for _, comp := range ro.order {
+		if !ro.used[comp] {
+			comps = append(comps, comp)
+		}
+	}
+	return comps
+}
+
+// isRecurrenceOverride reports whether comp overrides one instance of a recurring event
+func isRecurrenceOverride(comp *ical.Component) bool {
+	_, _, ok := overrideInstance(comp)
+	return ok
+}
+
+// overrideInstance returns the UID and original start time of the instance comp overrides
+func overrideInstance(comp *ical.Component) (string, time.Time, bool) {
+	uidProp := comp.Props.Get(ical.PropUID)
+	ridProp := comp.Props.Get(ical.PropRecurrenceID)
+	if uidProp == nil || uidProp.Value == "" || ridProp == nil {
+		return "", time.Time{}, false
+	}
+
+	recurrenceID, err := ridProp.DateTime(getTimezoneFromComponent(comp))
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return uidProp.Value, recurrenceID, true
+}
+
+// instanceKey identifies one occurrence of a recurring event independent of time zone
+func instanceKey(uid string, start time.Time) string {
+	return uid + "|" + start.UTC().Format(time.RFC3339)
+}
+
+// occurrenceID builds the stable event ID of one occurrence from its original start,
+// so an instance keeps its ID (and alert status) when it is moved
+func occurrenceID(uid string, originalStart time.Time) string {
+	return uid + "-" + originalStart.Format(time.RFC3339)
+}
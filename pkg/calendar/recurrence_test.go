@@ -0,0 +1,180 @@
+package calendar
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// icsFeed wraps components written one property per line into a VCALENDAR
+func icsFeed(components ...string) string {
+	body := "BEGIN:VCALENDAR\nVERSION:2.0\nPRODID:-//test//EN\n" + strings.Join(components, "\n") + "\nEND:VCALENDAR\n"
+	return strings.ReplaceAll(body, "\n", "\r\n")
+}
+
+// standupMaster is a daily 10:00-10:15 standup in Berlin, 08:00 UTC in October
+const standupMaster = `BEGIN:VEVENT
+UID:standup
+DTSTAMP:20261001T000000Z
+DTSTART;TZID=Europe/Berlin:20261001T100000
+DTEND;TZID=Europe/Berlin:20261001T101500
+RRULE:FREQ=DAILY
+SUMMARY:Standup
+END:VEVENT`
+
+// standupOverride overrides the standup at recurrenceID with props
+func standupOverride(recurrenceID, props string) string {
+	return "BEGIN:VEVENT\nUID:standup\nDTSTAMP:20261002T000000Z\n" + recurrenceID + "\n" + props + "\nEND:VEVENT"
+}
+
+// wantOccurrence is an expected event; start is in UTC
+type wantOccurrence struct {
+	id    string
+	title string
+	start string
+}
+
+func TestRecurrenceOverrides(t *testing.T) {
+	// The window holds the standups of October 16th, 17th and 18th
+	now := time.Date(2026, 10, 16, 6, 0, 0, 0, time.UTC)
+	lookahead := 72 * time.Hour
+
+	unchanged16 := wantOccurrence{"standup-2026-10-16T10:00:00+02:00", "Standup", "2026-10-16T08:00:00Z"}
+	unchanged17 := wantOccurrence{"standup-2026-10-17T10:00:00+02:00", "Standup", "2026-10-17T08:00:00Z"}
+	unchanged18 := wantOccurrence{"standup-2026-10-18T10:00:00+02:00", "Standup", "2026-10-18T08:00:00Z"}
+
+	tests := []struct {
+		name         string
+		feed         string
+		want         []wantOccurrence
+		wantFiltered []string // IDs of events dropped
+	}{
+		{
+			name: "no overrides",
+			feed: icsFeed(standupMaster),
+			want: []wantOccurrence{unchanged16, unchanged17, unchanged18},
+		},
+		{
+			name: "moved occurrence keeps its original ID",
+			feed: icsFeed(standupMaster, standupOverride(
+				"RECURRENCE-ID;TZID=Europe/Berlin:20261017T100000",
+				"DTSTART;TZID=Europe/Berlin:20261017T113000\nDTEND;TZID=Europe/Berlin:20261017T114500\nSUMMARY:Standup (late)")),
+			want: []wantOccurrence{unchanged16, {"standup-2026-10-17T10:00:00+02:00", "Standup (late)", "2026-10-17T09:30:00Z"}, unchanged18},
+		},
+		{
+			name: "override without an end keeps the series duration",
+			feed: icsFeed(standupMaster, standupOverride(
+				"RECURRENCE-ID;TZID=Europe/Berlin:20261017T100000",
+				"DTSTART;TZID=Europe/Berlin:20261017T140000\nSUMMARY:Standup")),
+			want: []wantOccurrence{unchanged16, {"standup-2026-10-17T10:00:00+02:00", "Standup", "2026-10-17T12:00:00Z"}, unchanged18},
+		},
+		{
+			name: "cancelled occurrence",
+			feed: icsFeed(standupMaster, standupOverride(
+				"RECURRENCE-ID;TZID=Europe/Berlin:20261017T100000",
+				"DTSTART;TZID=Europe/Berlin:20261017T100000\nDTEND;TZID=Europe/Berlin:20261017T101500\nSUMMARY:Standup\nSTATUS:CANCELLED")),
+			want:         []wantOccurrence{unchanged16, unchanged18},
+			wantFiltered: []string{"standup-2026-10-17T10:00:00+02:00"},
+		},
+		{
+			name: "override in another time zone than the master",
+			feed: icsFeed(standupMaster, standupOverride(
+				"RECURRENCE-ID;TZID=America/New_York:20261017T040000",
+				"DTSTART;TZID=America/New_York:20261017T060000\nDTEND;TZID=America/New_York:20261017T061500\nSUMMARY:Standup (from New York)")),
+			want: []wantOccurrence{unchanged16, {"standup-2026-10-17T10:00:00+02:00", "Standup (from New York)", "2026-10-17T10:00:00Z"}, unchanged18},
+		},
+		{
+			name: "override with a UTC recurrence ID",
+			feed: icsFeed(standupMaster, standupOverride(
+				"RECURRENCE-ID:20261017T080000Z",
+				"DTSTART:20261017T083000Z\nDTEND:20261017T084500Z\nSUMMARY:Standup (UTC)")),
+			want: []wantOccurrence{unchanged16, {"standup-2026-10-17T10:00:00+02:00", "Standup (UTC)", "2026-10-17T08:30:00Z"}, unchanged18},
+		},
+		{
+			name: "occurrence moved into the window from outside it",
+			feed: icsFeed(standupMaster, standupOverride(
+				"RECURRENCE-ID;TZID=America/New_York:20261020T040000",
+				"DTSTART;TZID=America/New_York:20261018T100000\nDTEND;TZID=America/New_York:20261018T101500\nSUMMARY:Standup (early)")),
+			want: []wantOccurrence{unchanged16, unchanged17, unchanged18, {"standup-2026-10-20T10:00:00+02:00", "Standup (early)", "2026-10-18T14:00:00Z"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseICalData(tt.feed, now, lookahead, nil, skipAllDay, "")
+			if err != nil {
+				t.Fatalf("parseICalData() error: %v", err)
+			}
+
+			sort.Slice(result.Events, func(i, j int) bool {
+				return result.Events[i].StartTime.Before(result.Events[j].StartTime)
+			})
+			got := []wantOccurrence{}
+			for _, event := range result.Events {
+				got = append(got, wantOccurrence{event.ID, event.Title, event.StartTime.UTC().Format(time.RFC3339)})
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("events\n  %v\nwant\n  %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("event %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+
+			filtered := []string{}
+			for _, f := range result.Filtered {
+				filtered = append(filtered, f.Event.ID)
+			}
+			for _, id := range tt.wantFiltered {
+				if !strings.Contains(strings.Join(filtered, " "), id) {
+					t.Errorf("filtered %v, want %s among them", filtered, id)
+				}
+			}
+		})
+	}
+}
+
+func TestAllDayRecurrenceOverride(t *testing.T) {
+	now := time.Date(2026, 10, 16, 6, 0, 0, 0, time.Local)
+	feed := icsFeed(`BEGIN:VEVENT
+UID:focus
+DTSTAMP:20261001T000000Z
+DTSTART;VALUE=DATE:20261001
+DTEND;VALUE=DATE:20261002
+RRULE:FREQ=DAILY
+SUMMARY:Focus day
+END:VEVENT`, `BEGIN:VEVENT
+UID:focus
+DTSTAMP:20261002T000000Z
+RECURRENCE-ID;VALUE=DATE:20261017
+DTSTART;VALUE=DATE:20261017
+DTEND;VALUE=DATE:20261018
+SUMMARY:Offsite
+END:VEVENT`)
+
+	result, err := parseICalData(feed, now, 48*time.Hour, nil, allDaySchedule(9*60), "")
+	if err != nil {
+		t.Fatalf("parseICalData() error: %v", err)
+	}
+
+	titles := make(map[string]string) // day -> title
+	for _, event := range result.Events {
+		if !event.AllDay {
+			t.Errorf("%q on %s is not all-day", event.Title, event.StartTime)
+		}
+		day := event.StartTime.In(time.Local).Format("2006-01-02")
+		if _, dup := titles[day]; dup {
+			t.Errorf("two events on %s", day)
+		}
+		titles[day] = event.Title
+	}
+
+	want := map[string]string{"2026-10-16": "Focus day", "2026-10-17": "Offsite", "2026-10-18": "Focus day"}
+	for day, title := range want {
+		if titles[day] != title {
+			t.Errorf("%s: %q, want %q (all: %v)", day, titles[day], title, titles)
+		}
+	}
+}
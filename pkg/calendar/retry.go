@@ -0,0 +1,124 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/borgmon/focus-breaker/pkg/clock"
+	"github.com/borgmon/focus-breaker/pkg/models"
+)
+
+// RetryPolicy controls how often a failing source is retried within one sync
+type RetryPolicy struct {
+	MaxAttempts    int           // Total attempts including the first one
+	InitialBackoff time.Duration // Wait before the second attempt; doubled after each failure
+	MaxBackoff     time.Duration // Upper bound for the wait between attempts
+	AttemptTimeout time.Duration // Timeout for a single attempt (0 means none)
+}
+
+// DefaultRetryPolicy retries twice, waiting 2s and then 4s
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 2 * time.Second,
+	MaxBackoff:     30 * time.Second,
+}
+
+// transientError marks a fetch error that may clear up on its own, like a dropped
+// connection or an overloaded server, and is worth retrying
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string {
+	return e.err.Error()
+}
+
+func (e *transientError) Unwrap() error {
+	return e.err
+}
+
+// transient marks err as worth retrying
+func transient(err error) error {
+	return &transientError{err: err}
+}
+
+// transientStatus marks err as worth retrying if statusCode says the server may
+// answer later: 408, 429 and 5xx
+func transientStatus(statusCode int, err error) error {
+	if statusCode == http.StatusRequestTimeout || statusCode == http.StatusTooManyRequests || statusCode >= 500 {
+		return transient(err)
+	}
+	return err
+}
+
+// isTransient reports whether err, or the fetch error behind a cache fallback, is
+// worth retrying
+func isTransient(err error) bool {
+	var transientErr *transientError
+	return errors.As(err, &transientErr)
+}
+
+// FetchEventsWithRetry calls FetchEvents until it succeeds, the attempts run out or
+// ctx is cancelled, backing off exponentially between attempts. Only transient errors
+// are retried; rejected credentials, bad settings and broken feeds fail the same way
+// every time and are returned at once. The result of the last attempt is returned,
+// including cached events on a *CacheFallbackError.
+func FetchEventsWithRetry(ctx context.Context, clk clock.Clock, source models.ICalSource, cache *FeedCache, policy RetryPolicy, lookahead time.Duration) (*FetchResult, error) {
+	attempts := policy.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := policy.InitialBackoff
+
+	var result *FetchResult
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		result, err = fetchAttempt(ctx, clk, source, cache, policy.AttemptTimeout, lookahead)
+		if err == nil {
+			return result, nil
+		}
+
+		if attempt == attempts || !isTransient(err) || ctx.Err() != nil {
+			break
+		}
+
+		log.Printf("Attempt %d/%d for '%s' failed, retrying in %v: %v", attempt, attempts, source.Name, backoff, unwrapFallback(err))
+
+		timer := clk.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, err
+		case <-timer.C():
+		}
+
+		backoff *= 2
+		if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+
+	return result, err
+}
+
+// fetchAttempt runs a single FetchEvents call bounded by timeout
+func fetchAttempt(ctx context.Context, clk clock.Clock, source models.ICalSource, cache *FeedCache, timeout, lookahead time.Duration) (*FetchResult, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return FetchEvents(ctx, clk, source, cache, lookahead)
+}
+
+// unwrapFallback returns the underlying fetch error of a cache fallback
+func unwrapFallback(err error) error {
+	var fallbackErr *CacheFallbackError
+	if errors.As(err, &fallbackErr) {
+		return fallbackErr.Err
+	}
+	return err
+}
@@ -0,0 +1,52 @@
+package calendar
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/borgmon/focus-breaker/pkg/clock"
+	"github.com/borgmon/focus-breaker/pkg/models"
+)
+
+func TestFetchEventsWithRetryRetriesOnlyTransientErrors(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		wantRequests int32
+	}{
+		{name: "unauthorized", status: http.StatusUnauthorized, wantRequests: 1},
+		{name: "forbidden", status: http.StatusForbidden, wantRequests: 1},
+		{name: "not found", status: http.StatusNotFound, wantRequests: 1},
+		{name: "not a calendar", status: http.StatusOK, body: "<html>Sign in</html>", wantRequests: 1},
+		{name: "too many requests", status: http.StatusTooManyRequests, wantRequests: 3},
+		{name: "server error", status: http.StatusServiceUnavailable, wantRequests: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			source := models.ICalSource{ID: "test", Name: "Test", URL: server.URL}
+			_, err := FetchEventsWithRetry(context.Background(), clock.Real, source, nil, policy, 24*time.Hour)
+			if err == nil {
+				t.Fatal("FetchEventsWithRetry() succeeded, want an error")
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("server got %d request(s), want %d (error: %v)", got, tt.wantRequests, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package calendar
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/borgmon/focus-breaker/pkg/models"
+)
+
+// Rule names, as recorded in filterStats and shown to the user
+const (
+	ruleIncludeTitle = "include title"
+	ruleExcludeTitle = "exclude title"
+	ruleOrganizer    = "organizer"
+	ruleCategory     = "category"
+	ruleSkipFree     = "free event"
+	ruleMaxAttendees = "max attendees"
+)
+
+// sourceRules is the compiled form of a source's FilterRules
+type sourceRules struct {
+	rules        models.FilterRules
+	includeTitle *regexp.Regexp
+	excludeTitle *regexp.Regexp
+}
+
+// newSourceRules compiles the title patterns of rules
+func newSourceRules(rules models.FilterRules) (*sourceRules, error) {
+	sr := &sourceRules{rules: rules}
+
+	var err error
+	if rules.IncludeTitle != "" {
+		if sr.includeTitle, err = regexp.Compile(rules.IncludeTitle); err != nil {
+			return nil, fmt.Errorf("invalid include title pattern: %w", err)
+		}
+	}
+	if rules.ExcludeTitle != "" {
+		if sr.excludeTitle, err = regexp.Compile(rules.ExcludeTitle); err != nil {
+			return nil, fmt.Errorf("invalid exclude title pattern: %w", err)
+		}
+	}
+
+	return sr, nil
+}
+
+// ValidateFilterRules returns an error if rules can't be compiled
+func ValidateFilterRules(rules models.FilterRules) error {
+	_, err := newSourceRules(rules)
+	return err
+}
+
+// allDayAlertLayout is the format of ICalSource.AllDayAlertTime
+const allDayAlertLayout = "15:04"
+
+// allDaySchedule is when a source's all-day events are alerted: the minute of the
+// local day, on their first day, or skipAllDay
+type allDaySchedule int
+
+// skipAllDay drops all-day events instead of alerting them
+const skipAllDay allDaySchedule = -1
+
+// parseAllDayAlertTime parses an ICalSource.AllDayAlertTime; empty skips all-day events
+func parseAllDayAlertTime(value string) (allDaySchedule, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return skipAllDay, nil
+	}
+	t, err := time.Parse(allDayAlertLayout, value)
+	if err != nil {
+		return skipAllDay, fmt.Errorf("invalid all-day alert time %q, expected HH:MM", value)
+	}
+	return allDaySchedule(t.Hour()*60 + t.Minute()), nil
+}
+
+// ValidateAllDayAlertTime returns an error if value is neither empty nor HH:MM
+func ValidateAllDayAlertTime(value string) error {
+	_, err := parseAllDayAlertTime(value)
+	return err
+}
+
+// alerted reports whether all-day events are alerted
+func (s allDaySchedule) alerted() bool {
+	return s >= 0
+}
+
+// apply sets when an all-day event is alerted: the scheduled time of day, in local
+// time, on its first day
+func (s allDaySchedule) apply(event *models.Event) {
+	if !event.AllDay || !s.alerted() {
+		return
+	}
+	year, month, day := event.StartTime.In(time.Local).Date()
+	// Wall-clock time, so the alert stays at e.g. 09:00 on days the clocks change
+	event.AlertAt = time.Date(year, month, day, 0, int(s), 0, 0, time.Local)
+}
+
+// rejectingRule returns the name of the first rule that drops event, or "" if it is kept
+func (sr *sourceRules) rejectingRule(event models.Event) string {
+	if sr == nil {
+		return ""
+	}
+
+	if sr.includeTitle != nil && !sr.includeTitle.MatchString(event.Title) {
+		return ruleIncludeTitle
+	}
+	if sr.excludeTitle != nil && sr.excludeTitle.MatchString(event.Title) {
+		return ruleExcludeTitle
+	}
+	if len(sr.rules.Organizers) > 0 && !matchesOrganizer(sr.rules.Organizers, event.Organizer.Email) {
+		return ruleOrganizer
+	}
+	if len(sr.rules.Categories) > 0 && !hasCategory(sr.rules.Categories, event.Categories) {
+		return ruleCategory
+	}
+	if sr.rules.SkipFree && event.IsFree() {
+		return ruleSkipFree
+	}
+	if sr.rules.MaxAttendees > 0 && len(event.Attendees) > sr.rules.MaxAttendees {
+		return ruleMaxAttendees
+	}
+
+	return ""
+}
+
+// matchesOrganizer reports whether email is one of organizers, or in one of their "@domain"s
+func matchesOrganizer(organizers []string, email string) bool {
+	if email == "" {
+		return false
+	}
+
+	email = strings.ToLower(email)
+	for _, organizer := range organizers {
+		organizer = strings.ToLower(strings.TrimSpace(organizer))
+		if organizer == email || (strings.HasPrefix(organizer, "@") && strings.HasSuffix(email, organizer)) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasCategory reports whether any of categories is among wanted, ignoring case
+func hasCategory(wanted, categories []string) bool {
+	for _, category := range categories {
+		for _, w := range wanted {
+			if strings.EqualFold(strings.TrimSpace(w), category) {
+				return true
+			}
+		}
+	}
+	return false
+}
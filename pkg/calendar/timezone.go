@@ -1,83 +1,154 @@
 package calendar
 
 import (
+	"fmt"
+	"log"
 	"strings"
 	"time"
 
 	"github.com/emersion/go-ical"
 )
 
-// Map of common Windows timezone names to IANA timezone names
-var windowsToIANA = map[string]string{
-	"Pacific Standard Time":        "America/Los_Angeles",
-	"Mountain Standard Time":       "America/Denver",
-	"Central Standard Time":        "America/Chicago",
-	"Eastern Standard Time":        "America/New_York",
-	"Atlantic Standard Time":       "America/Halifax",
-	"Alaskan Standard Time":        "America/Anchorage",
-	"Hawaiian Standard Time":       "Pacific/Honolulu",
-	"GMT Standard Time":            "Europe/London",
-	"Central Europe Standard Time": "Europe/Paris",
-	"China Standard Time":          "Asia/Shanghai",
-	"Tokyo Standard Time":          "Asia/Tokyo",
-	"India Standard Time":          "Asia/Kolkata",
-	"AUS Eastern Standard Time":    "Australia/Sydney",
+// propFeedTimezone is added to an event whose TZID is only defined by a VTIMEZONE in
+// the feed. Its value is the zone's POSIX TZ rule and its TZID parameter the original
+// TZID; the event's date-times are made floating so they are read in that zone.
+const propFeedTimezone = "X-FOCUS-BREAKER-TIMEZONE"
+
+// ianaTimezone returns the IANA name for a TZID that is a Windows zone name, an IANA
+// name, or an IANA name behind a path prefix such as "/mozilla.org/20050126_1/Europe/Berlin"
+func ianaTimezone(tzid string) (string, bool) {
+	if ianaName, ok := windowsToIANA[tzid]; ok {
+		return ianaName, true
+	}
+	if _, err := time.LoadLocation(tzid); err == nil {
+		return tzid, true
+	}
+
+	parts := strings.Split(strings.Trim(tzid, "/"), "/")
+	for i := 1; i < len(parts)-1; i++ {
+		candidate := strings.Join(parts[i:], "/")
+		if _, err := time.LoadLocation(candidate); err == nil {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// ValidateTimezone returns an error if name is neither an IANA nor a Windows time zone
+func ValidateTimezone(name string) error {
+	if _, ok := ianaTimezone(name); !ok {
+		return fmt.Errorf("unknown time zone %q, expected a name like Europe/Berlin", name)
+	}
+	return nil
+}
+
+// isFloatingDateTime reports whether prop holds date-times without a zone, which
+// RFC 5545 calls floating: neither TZID nor a UTC "Z" suffix, and not a DATE
+func isFloatingDateTime(prop *ical.Prop) bool {
+	if prop.Params.Get(ical.ParamTimezoneID) != "" {
+		return false
+	}
+	first, _, _ := strings.Cut(strings.TrimSpace(prop.Value), ",")
+	return strings.Contains(first, "T") && !strings.HasSuffix(first, "Z")
+}
+
+// dateTimeProps returns the properties of comp that may carry a TZID
+func dateTimeProps(comp *ical.Component) []*ical.Prop {
+	props := []*ical.Prop{}
+	for _, name := range []string{ical.PropDateTimeStart, ical.PropDateTimeEnd, ical.PropRecurrenceID} {
+		if prop := comp.Props.Get(name); prop != nil {
+			props = append(props, prop)
+		}
+	}
+	for _, name := range []string{ical.PropExceptionDates, ical.PropRecurrenceDates} {
+		for i := range comp.Props[name] {
+			props = append(props, &comp.Props[name][i])
+		}
+	}
+	return props
 }
 
-// normalizeComponentTimezones fixes Windows timezone names in a component before processing
-func normalizeComponentTimezones(comp *ical.Component) {
-	// Check DTSTART
+// normalizeComponentTimezones rewrites the TZIDs of a component so go-ical can load
+// them: Windows names become IANA names, and zones defined only by a VTIMEZONE in the
+// feed are resolved through propFeedTimezone. Floating date-times, and those with an
+// unknown TZID, are given floatingTZID if it is set and read as local time otherwise.
+func normalizeComponentTimezones(comp *ical.Component, zones feedTimezones, floatingTZID string) {
+	// The zone of DTSTART is the zone of the event
+	var eventTZID string
 	if dtstart := comp.Props.Get(ical.PropDateTimeStart); dtstart != nil {
-		if tzid := dtstart.Params.Get(ical.ParamTimezoneID); tzid != "" {
-			if ianaName, ok := windowsToIANA[tzid]; ok {
-				dtstart.Params.Set(ical.ParamTimezoneID, ianaName)
+		eventTZID = dtstart.Params.Get(ical.ParamTimezoneID)
+	}
+
+	for _, prop := range dateTimeProps(comp) {
+		tzid := prop.Params.Get(ical.ParamTimezoneID)
+		if tzid == "" {
+			if floatingTZID != "" && isFloatingDateTime(prop) {
+				prop.Params.Set(ical.ParamTimezoneID, floatingTZID)
 			}
+			continue
 		}
-	}
 
-	// Check DTEND
-	if dtend := comp.Props.Get(ical.PropDateTimeEnd); dtend != nil {
-		if tzid := dtend.Params.Get(ical.ParamTimezoneID); tzid != "" {
-			if ianaName, ok := windowsToIANA[tzid]; ok {
-				dtend.Params.Set(ical.ParamTimezoneID, ianaName)
+		if ianaName, ok := ianaTimezone(tzid); ok {
+			if ianaName != tzid {
+				prop.Params.Set(ical.ParamTimezoneID, ianaName)
 			}
+			continue
 		}
-	}
 
-	// Check EXDATE properties
-	for _, exdate := range comp.Props.Values(ical.PropExceptionDates) {
-		if tzid := exdate.Params.Get(ical.ParamTimezoneID); tzid != "" {
-			if ianaName, ok := windowsToIANA[tzid]; ok {
-				exdate.Params.Set(ical.ParamTimezoneID, ianaName)
+		// go-ical can't load this TZID, so the value is made floating and read
+		// in the zone the feed defines for it, or as a floating time
+		prop.Params.Del(ical.ParamTimezoneID)
+
+		rule, err := zones.rule(tzid)
+		if err != nil {
+			if floatingTZID != "" {
+				prop.Params.Set(ical.ParamTimezoneID, floatingTZID)
 			}
+			log.Printf("  [TIMEZONE] Unknown TZID %q, reading its times as floating times: %v", tzid, err)
+			continue
+		}
+
+		if tzid == eventTZID {
+			feedZone := ical.NewProp(propFeedTimezone)
+			feedZone.Value = rule
+			feedZone.Params.Set(ical.ParamTimezoneID, tzid)
+			comp.Props.Set(feedZone)
+			continue
 		}
-	}
 
-	// Check RDATE properties
-	for _, rdate := range comp.Props.Values(ical.PropRecurrenceDates) {
-		if tzid := rdate.Params.Get(ical.ParamTimezoneID); tzid != "" {
-			if ianaName, ok := windowsToIANA[tzid]; ok {
-				rdate.Params.Set(ical.ParamTimezoneID, ianaName)
+		// A different custom zone than the event's own: pin the instants in UTC
+		loc, err := locationFromRule(tzid, rule)
+		if err != nil {
+			log.Printf("  [TIMEZONE] %v", err)
+			continue
+		}
+		values := strings.Split(prop.Value, ",")
+		for i, value := range values {
+			if t, err := time.ParseInLocation("20060102T150405", value, loc); err == nil {
+				values[i] = t.UTC().Format("20060102T150405Z")
 			}
 		}
+		prop.Value = strings.Join(values, ",")
 	}
 }
 
 // getTimezoneFromComponent tries to determine the timezone for a component
 func getTimezoneFromComponent(comp *ical.Component) *time.Location {
+	// Zone defined by the feed's own VTIMEZONE
+	if feedZone := comp.Props.Get(propFeedTimezone); feedZone != nil {
+		if loc, err := locationFromRule(feedZone.Params.Get(ical.ParamTimezoneID), feedZone.Value); err == nil {
+			return loc
+		}
+	}
+
 	// Check DTSTART for timezone
 	if dtstart := comp.Props.Get(ical.PropDateTimeStart); dtstart != nil {
 		if tzid := dtstart.Params.Get(ical.ParamTimezoneID); tzid != "" {
-			// Try to map Windows timezone to IANA
-			if ianaName, ok := windowsToIANA[tzid]; ok {
+			if ianaName, ok := ianaTimezone(tzid); ok {
 				if loc, err := time.LoadLocation(ianaName); err == nil {
 					return loc
 				}
 			}
-			// Try original timezone name
-			if loc, err := time.LoadLocation(tzid); err == nil {
-				return loc
-			}
 		}
 
 		// Check if it's a UTC time (ends with Z)
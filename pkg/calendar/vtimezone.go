@@ -0,0 +1,350 @@
+package calendar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emersion/go-ical"
+)
+
+// feedTimezones holds the VTIMEZONE definitions of one feed
+type feedTimezones struct {
+	defs map[string]*ical.Component // key: TZID
+	now  time.Time                  // Decides which observances are current
+}
+
+// newFeedTimezones collects the VTIMEZONE components among comps
+func newFeedTimezones(comps []*ical.Component, now time.Time) feedTimezones {
+	zones := feedTimezones{defs: map[string]*ical.Component{}, now: now}
+	for _, comp := range comps {
+		if comp.Name != ical.CompTimezone {
+			continue
+		}
+		if tzidProp := comp.Props.Get(ical.PropTimezoneID); tzidProp != nil && tzidProp.Value != "" {
+			zones.defs[tzidProp.Value] = comp
+		}
+	}
+	return zones
+}
+
+// rule returns the POSIX TZ rule equivalent to the feed's VTIMEZONE for tzid
+func (zones feedTimezones) rule(tzid string) (string, error) {
+	comp, exists := zones.defs[tzid]
+	if !exists {
+		return "", fmt.Errorf("no VTIMEZONE for %q", tzid)
+	}
+	return vtimezoneRule(comp, zones.now)
+}
+
+// observance is one STANDARD or DAYLIGHT block of a VTIMEZONE
+type observance struct {
+	start      time.Time // DTSTART as wall time, in UTC for comparison only
+	offsetFrom int       // seconds east of UTC before the transition
+	offsetTo   int       // seconds east of UTC after the transition
+	name       string    // TZNAME, if given
+	rrule      map[string]string
+}
+
+// vtimezoneRule converts the observances of a VTIMEZONE that are current at now into
+// a POSIX TZ rule such as "<NZST>-12<NZDT>,M9.5.0,M4.1.0/3". Zones whose current
+// daylight saving rules can't be expressed that way keep their standard offset.
+func vtimezoneRule(comp *ical.Component, now time.Time) (string, error) {
+	var standard, daylight *observance
+	for _, child := range comp.Children {
+		if child.Name != ical.CompTimezoneStandard && child.Name != ical.CompTimezoneDaylight {
+			continue
+		}
+		obs, err := parseObservance(child)
+		if err != nil {
+			return "", err
+		}
+
+		// The latest block of each kind holds the rules in force today
+		latest := &standard
+		if child.Name == ical.CompTimezoneDaylight {
+			latest = &daylight
+		}
+		if *latest == nil || obs.start.After((*latest).start) {
+			*latest = obs
+		}
+	}
+
+	if standard == nil && daylight == nil {
+		return "", fmt.Errorf("VTIMEZONE has no STANDARD or DAYLIGHT rules")
+	}
+	if standard == nil {
+		// Zones that are on "daylight" time all year
+		return posixName(daylight.name, daylight.offsetTo) + posixOffset(daylight.offsetTo), nil
+	}
+
+	rule := posixName(standard.name, standard.offsetTo) + posixOffset(standard.offsetTo)
+	if daylight == nil || !standard.recursAfter(now) || !daylight.recursAfter(now) {
+		return rule, nil
+	}
+
+	var toStandard string
+	toDaylight, err := posixDate(daylight)
+	if err == nil {
+		toStandard, err = posixDate(standard)
+	}
+	if err != nil {
+		log.Printf("  [TIMEZONE] Daylight saving rules of %q not understood, using its standard offset: %v", vtimezoneID(comp), err)
+		return rule, nil
+	}
+
+	rule += posixName(daylight.name, daylight.offsetTo)
+	if daylight.offsetTo-standard.offsetTo != 3600 {
+		rule += posixOffset(daylight.offsetTo)
+	}
+	return rule + "," + toDaylight + "," + toStandard, nil
+}
+
+// vtimezoneID returns the TZID of a VTIMEZONE
+func vtimezoneID(comp *ical.Component) string {
+	if tzidProp := comp.Props.Get(ical.PropTimezoneID); tzidProp != nil {
+		return tzidProp.Value
+	}
+	return ""
+}
+
+// parseObservance reads the DTSTART, offsets, name and yearly rule of an observance
+func parseObservance(comp *ical.Component) (*observance, error) {
+	obs := &observance{rrule: map[string]string{}}
+
+	startProp := comp.Props.Get(ical.PropDateTimeStart)
+	if startProp == nil {
+		return nil, fmt.Errorf("%s without DTSTART", comp.Name)
+	}
+	start, err := time.Parse("20060102T150405", strings.TrimSuffix(startProp.Value, "Z"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s DTSTART %q: %w", comp.Name, startProp.Value, err)
+	}
+	obs.start = start
+
+	if obs.offsetFrom, err = parseUTCOffset(comp.Props.Get(ical.PropTimezoneOffsetFrom)); err != nil {
+		return nil, err
+	}
+	if obs.offsetTo, err = parseUTCOffset(comp.Props.Get(ical.PropTimezoneOffsetTo)); err != nil {
+		return nil, err
+	}
+	if nameProp := comp.Props.Get(ical.PropTimezoneName); nameProp != nil {
+		obs.name = nameProp.Value
+	}
+
+	if rruleProp := comp.Props.Get(ical.PropRecurrenceRule); rruleProp != nil {
+		for _, part := range strings.Split(rruleProp.Value, ";") {
+			if key, value, found := strings.Cut(part, "="); found {
+				obs.rrule[strings.ToUpper(key)] = strings.ToUpper(value)
+			}
+		}
+	}
+
+	return obs, nil
+}
+
+// recursAfter reports whether the observance has a yearly rule still in force at now
+func (obs *observance) recursAfter(now time.Time) bool {
+	if obs.rrule["FREQ"] != "YEARLY" {
+		return false
+	}
+	if until := obs.rrule["UNTIL"]; until != "" {
+		if t, err := time.Parse("20060102T150405", strings.TrimSuffix(until, "Z")); err == nil && t.Before(now) {
+			return false
+		}
+	}
+	return true
+}
+
+// weekdays maps RRULE day names to POSIX day numbers
+var weekdays = map[string]int{"SU": 0, "MO": 1, "TU": 2, "WE": 3, "TH": 4, "FR": 5, "SA": 6}
+
+// posixDate converts the yearly rule of an observance to a POSIX date and time,
+// e.g. "M3.5.0/2" for the last Sunday of March at 02:00 local time. A weekday on or
+// after a day that doesn't start a week is written the way tzdata does: as an
+// earlier weekday of that week plus whole days, e.g. "M3.4.4/26" for the Friday on or
+// after March 23rd at 02:00.
+func posixDate(obs *observance) (string, error) {
+	month, err := strconv.Atoi(obs.rrule["BYMONTH"])
+	if err != nil || month < 1 || month > 12 {
+		return "", fmt.Errorf("unsupported BYMONTH %q", obs.rrule["BYMONTH"])
+	}
+
+	var date string
+	shiftDays := 0
+	byDay, byMonthDay := obs.rrule["BYDAY"], obs.rrule["BYMONTHDAY"]
+	switch {
+	case byDay != "" && len(byDay) >= 2:
+		weekday, known := weekdays[byDay[len(byDay)-2:]]
+		if !known {
+			return "", fmt.Errorf("unsupported BYDAY %q", byDay)
+		}
+
+		week := 0
+		if ordinal := byDay[:len(byDay)-2]; ordinal != "" {
+			n, err := strconv.Atoi(strings.TrimPrefix(ordinal, "+"))
+			if err != nil || n == 0 || n < -1 || n > 5 {
+				return "", fmt.Errorf("unsupported BYDAY %q", byDay)
+			}
+			week = n
+			if n == -1 {
+				week = 5
+			}
+		} else if days := strings.Split(byMonthDay, ","); len(days) == 7 {
+			// "BYDAY=SU;BYMONTHDAY=8,9,10,11,12,13,14" is the second Sunday
+			sorted := make([]int, 0, len(days))
+			for _, day := range days {
+				n, err := strconv.Atoi(day)
+				if err != nil {
+					return "", fmt.Errorf("unsupported BYMONTHDAY %q", byMonthDay)
+				}
+				sorted = append(sorted, n)
+			}
+			sort.Ints(sorted)
+			if sorted[0] < 1 || sorted[0] > 28 || sorted[6] != sorted[0]+6 {
+				return "", fmt.Errorf("unsupported BYMONTHDAY %q", byMonthDay)
+			}
+			// Days 8-14 hold the second of each weekday; days 9-15 the second of the
+			// weekday before, a day later
+			week = (sorted[0]-1)/7 + 1
+			shiftDays = (sorted[0] - 1) % 7
+			weekday = (weekday - shiftDays + 7) % 7
+		} else {
+			return "", fmt.Errorf("unsupported BYDAY %q without ordinal", byDay)
+		}
+		date = fmt.Sprintf("M%d.%d.%d", month, week, weekday)
+
+	case byMonthDay != "":
+		day, err := strconv.Atoi(byMonthDay)
+		if err != nil || day < 1 {
+			return "", fmt.Errorf("unsupported BYMONTHDAY %q", byMonthDay)
+		}
+		// Julian day 1-365 that ignores February 29th
+		date = fmt.Sprintf("J%d", time.Date(2001, time.Month(month), day, 0, 0, 0, 0, time.UTC).YearDay())
+
+	default:
+		return "", fmt.Errorf("yearly rule without BYDAY or BYMONTHDAY")
+	}
+
+	clock := shiftDays*24*3600 + obs.start.Hour()*3600 + obs.start.Minute()*60 + obs.start.Second()
+	if clock != 2*3600 {
+		date += "/" + posixClock(clock)
+	}
+	return date, nil
+}
+
+// posixName quotes an abbreviation for a POSIX TZ rule, deriving one from the offset if needed
+func posixName(name string, offset int) string {
+	clean := strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '+' || r == '-' {
+			return r
+		}
+		return -1
+	}, name)
+	if len(clean) < 3 {
+		sign := "+"
+		if offset < 0 {
+			sign, offset = "-", -offset
+		}
+		clean = fmt.Sprintf("%s%02d%02d", sign, offset/3600, offset%3600/60)
+	}
+	return "<" + clean + ">"
+}
+
+// posixOffset formats an offset east of UTC the POSIX way, which counts west of UTC
+func posixOffset(offset int) string {
+	if offset > 0 {
+		return "-" + posixClock(offset)
+	}
+	return posixClock(-offset)
+}
+
+// posixClock formats seconds as h[:mm[:ss]]
+func posixClock(seconds int) string {
+	s := strconv.Itoa(seconds / 3600)
+	if rest := seconds % 3600; rest != 0 {
+		s += fmt.Sprintf(":%02d", rest/60)
+		if rest%60 != 0 {
+			s += fmt.Sprintf(":%02d", rest%60)
+		}
+	}
+	return s
+}
+
+// parseUTCOffset parses a TZOFFSETFROM or TZOFFSETTO value such as "+0530" to seconds
+func parseUTCOffset(prop *ical.Prop) (int, error) {
+	if prop == nil {
+		return 0, fmt.Errorf("missing UTC offset")
+	}
+
+	value := strings.TrimSpace(prop.Value)
+	if len(value) != 5 && len(value) != 7 {
+		return 0, fmt.Errorf("invalid UTC offset %q", value)
+	}
+	sign := 1
+	switch value[0] {
+	case '-':
+		sign = -1
+	case '+':
+	default:
+		return 0, fmt.Errorf("invalid UTC offset %q", value)
+	}
+
+	seconds := 0
+	for i, unit := range []int{3600, 60, 1} {
+		if 1+2*i+2 > len(value) {
+			break
+		}
+		n, err := strconv.Atoi(value[1+2*i : 1+2*i+2])
+		if err != nil {
+			return 0, fmt.Errorf("invalid UTC offset %q", value)
+		}
+		seconds += n * unit
+	}
+	return sign * seconds, nil
+}
+
+var ruleLocations sync.Map // key: name + "\x00" + rule, value: *time.Location
+
+// locationFromRule returns a location named name that follows a POSIX TZ rule for all time
+func locationFromRule(name, rule string) (*time.Location, error) {
+	key := name + "\x00" + rule
+	if loc, cached := ruleLocations.Load(key); cached {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocationFromTZData(name, tzifFromRule(rule))
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone rule %q: %w", rule, err)
+	}
+	ruleLocations.Store(key, loc)
+	return loc, nil
+}
+
+// tzifFromRule builds TZif version 2 data without transitions, so the footer rule
+// alone decides the offset at every instant
+func tzifFromRule(rule string) []byte {
+	var buf bytes.Buffer
+
+	// Both the 32-bit and the 64-bit block hold a single placeholder zone
+	for i := 0; i < 2; i++ {
+		buf.WriteString("TZif2")
+		buf.Write(make([]byte, 15))
+		// isutcnt, isstdcnt, leapcnt, timecnt, typecnt, charcnt
+		for _, count := range []uint32{0, 0, 0, 0, 1, 4} {
+			binary.Write(&buf, binary.BigEndian, count)
+		}
+		binary.Write(&buf, binary.BigEndian, int32(0)) // utoff
+		buf.Write([]byte{0, 0})                        // isdst, abbrind
+		buf.WriteString("UTC\x00")
+	}
+
+	buf.WriteString("\n" + rule + "\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,151 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-ical"
+)
+
+// parseVTimezone decodes a VTIMEZONE written without its surrounding VCALENDAR
+func parseVTimezone(t *testing.T, vtimezone string) *ical.Component {
+	t.Helper()
+
+	body := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//test//EN\r\n" +
+		strings.ReplaceAll(strings.TrimSpace(vtimezone), "\n", "\r\n") +
+		"\r\nEND:VCALENDAR\r\n"
+	cal, err := ical.NewDecoder(strings.NewReader(body)).Decode()
+	if err != nil {
+		t.Fatalf("decoding VTIMEZONE: %v", err)
+	}
+	return cal.Children[0]
+}
+
+func TestVTimezoneRule(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		vtimezone string
+		wantRule  string
+		offsets   map[string]int // UTC instant -> expected offset in hours
+	}{
+		{
+			name: "New Zealand",
+			vtimezone: `
+BEGIN:VTIMEZONE
+TZID:New Zealand Standard Time
+BEGIN:DAYLIGHT
+TZOFFSETFROM:+1200
+TZOFFSETTO:+1300
+TZNAME:NZDT
+DTSTART:20070930T020000
+RRULE:FREQ=YEARLY;BYMONTH=9;BYDAY=-1SU
+END:DAYLIGHT
+BEGIN:STANDARD
+TZOFFSETFROM:+1300
+TZOFFSETTO:+1200
+TZNAME:NZST
+DTSTART:20080406T030000
+RRULE:FREQ=YEARLY;BYMONTH=4;BYDAY=1SU
+END:STANDARD
+END:VTIMEZONE`,
+			wantRule: "<NZST>-12<NZDT>,M9.5.0,M4.1.0/3",
+			offsets: map[string]int{
+				"2027-01-15T00:00:00Z": 13,
+				"2027-07-15T00:00:00Z": 12,
+				"2027-09-25T13:59:00Z": 12, // Sunday September 26th, 01:59 NZST
+				"2027-09-25T14:00:00Z": 13, // 03:00 NZDT
+				"2027-04-03T13:59:00Z": 13, // Sunday April 4th, 02:59 NZDT
+				"2027-04-03T14:00:00Z": 12, // 02:00 NZST
+			},
+		},
+		{
+			name: "Israel",
+			vtimezone: `
+BEGIN:VTIMEZONE
+TZID:Israel Standard Time
+BEGIN:DAYLIGHT
+TZOFFSETFROM:+0200
+TZOFFSETTO:+0300
+TZNAME:IDT
+DTSTART:20130329T020000
+RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=FR;BYMONTHDAY=23,24,25,26,27,28,29
+END:DAYLIGHT
+BEGIN:STANDARD
+TZOFFSETFROM:+0300
+TZOFFSETTO:+0200
+TZNAME:IST
+DTSTART:20131027T020000
+RRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU
+END:STANDARD
+END:VTIMEZONE`,
+			wantRule: "<IST>-2<IDT>,M3.4.4/26,M10.5.0",
+			offsets: map[string]int{
+				"2027-01-15T00:00:00Z": 2,
+				"2027-07-15T00:00:00Z": 3,
+				"2027-03-25T23:59:00Z": 2, // Friday March 26th, 01:59 IST
+				"2027-03-26T00:00:00Z": 3, // 03:00 IDT
+				"2028-03-23T23:59:00Z": 2, // Friday March 24th, 01:59 IST
+				"2028-03-24T00:00:00Z": 3,
+				"2027-10-30T22:59:00Z": 3, // Sunday October 31st, 01:59 IDT
+				"2027-10-30T23:00:00Z": 2, // 01:00 IST
+			},
+		},
+		{
+			name: "Brazil after daylight saving was abolished",
+			vtimezone: `
+BEGIN:VTIMEZONE
+TZID:E. South America Standard Time
+BEGIN:DAYLIGHT
+TZOFFSETFROM:-0300
+TZOFFSETTO:-0200
+TZNAME:-02
+DTSTART:20181104T000000
+RRULE:FREQ=YEARLY;UNTIL=20181104T030000Z;BYMONTH=11;BYDAY=1SU
+END:DAYLIGHT
+BEGIN:STANDARD
+TZOFFSETFROM:-0200
+TZOFFSETTO:-0300
+TZNAME:-03
+DTSTART:20190217T000000
+END:STANDARD
+END:VTIMEZONE`,
+			wantRule: "<-03>3",
+			offsets: map[string]int{
+				"2027-01-15T00:00:00Z": -3,
+				"2027-07-15T00:00:00Z": -3,
+				"2027-11-07T12:00:00Z": -3,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comp := parseVTimezone(t, tt.vtimezone)
+
+			rule, err := vtimezoneRule(comp, now)
+			if err != nil {
+				t.Fatalf("vtimezoneRule() error: %v", err)
+			}
+			if rule != tt.wantRule {
+				t.Errorf("vtimezoneRule() = %q, want %q", rule, tt.wantRule)
+			}
+
+			loc, err := locationFromRule(tt.name, rule)
+			if err != nil {
+				t.Fatalf("locationFromRule(%q) error: %v", rule, err)
+			}
+			for instant, wantHours := range tt.offsets {
+				at, err := time.Parse(time.RFC3339, instant)
+				if err != nil {
+					t.Fatalf("bad test instant %q: %v", instant, err)
+				}
+				if _, offset := at.In(loc).Zone(); offset != wantHours*3600 {
+					t.Errorf("offset at %s = %+.1fh, want %+dh", instant, float64(offset)/3600, wantHours)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package calendar
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/borgmon/focus-breaker/pkg/models"
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce collapses the burst of events an editor or exporter produces for one save
+const watchDebounce = 500 * time.Millisecond
+
+// FileWatcher watches local file:// sources and reports when their .ics data changes
+type FileWatcher struct {
+	mu       sync.Mutex
+	watcher  *fsnotify.Watcher
+	onChange func(models.ICalSource)
+
+	// Map of source ID to watched local source
+	sources map[string]watchedSource
+
+	// Set of directories currently registered with fsnotify
+	dirs map[string]bool
+
+	// Map of source ID to pending debounce timer
+	timers map[string]*time.Timer
+}
+
+// watchedSource is a local source resolved to the path it reads from
+type watchedSource struct {
+	source models.ICalSource
+	path   string // File or directory the source reads
+	dir    string // Directory registered with fsnotify
+	isDir  bool
+}
+
+// NewFileWatcher creates a FileWatcher that calls onChange from its own goroutine
+func NewFileWatcher(onChange func(models.ICalSource)) (*FileWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	fw := &FileWatcher{
+		watcher:  watcher,
+		onChange: onChange,
+		sources:  make(map[string]watchedSource),
+		dirs:     make(map[string]bool),
+		timers:   make(map[string]*time.Timer),
+	}
+
+	go fw.run()
+
+	return fw, nil
+}
+
+// Watch replaces the set of watched sources; non-local sources are ignored
+func (fw *FileWatcher) Watch(sources []models.ICalSource) {
+	if fw == nil {
+		return
+	}
+
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	fw.sources = make(map[string]watchedSource)
+	wantedDirs := make(map[string]bool)
+
+	for _, source := range sources {
+		if !source.IsLocal() {
+			continue
+		}
+
+		path, err := LocalPath(source.URL)
+		if err != nil {
+			log.Printf("Warning: cannot watch '%s': %v", source.Name, err)
+			continue
+		}
+
+		// Watch the parent directory of single files so atomic saves (write + rename) are seen
+		ws := watchedSource{source: source, path: path, dir: filepath.Dir(path)}
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			ws.isDir = true
+			ws.dir = path
+		}
+
+		fw.sources[source.ID] = ws
+		wantedDirs[ws.dir] = true
+	}
+
+	for dir := range fw.dirs {
+		if !wantedDirs[dir] {
+			fw.watcher.Remove(dir)
+			delete(fw.dirs, dir)
+		}
+	}
+
+	for dir := range wantedDirs {
+		if fw.dirs[dir] {
+			continue
+		}
+		if err := fw.watcher.Add(dir); err != nil {
+			log.Printf("Warning: failed to watch %s: %v", dir, err)
+			continue
+		}
+		fw.dirs[dir] = true
+		log.Printf("Watching %s for calendar changes", dir)
+	}
+}
+
+// Close stops watching and cancels pending change notifications
+func (fw *FileWatcher) Close() {
+	if fw == nil {
+		return
+	}
+
+	fw.mu.Lock()
+	for _, timer := range fw.timers {
+		timer.Stop()
+	}
+	fw.timers = make(map[string]*time.Timer)
+	fw.mu.Unlock()
+
+	fw.watcher.Close()
+}
+
+func (fw *FileWatcher) run() {
+	for {
+		select {
+		case event, ok := <-fw.watcher.Events:
+			if !ok {
+				return
+			}
+			fw.handleEvent(event)
+		case err, ok := <-fw.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("File watcher error: %v", err)
+		}
+	}
+}
+
+// handleEvent schedules a reload for every source affected by a filesystem event
+func (fw *FileWatcher) handleEvent(event fsnotify.Event) {
+	if event.Op == fsnotify.Chmod {
+		return
+	}
+
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	for id, ws := range fw.sources {
+		var affected bool
+		if ws.isDir {
+			affected = filepath.Dir(event.Name) == ws.path && isICSFile(event.Name)
+		} else {
+			affected = event.Name == ws.path
+		}
+		if !affected {
+			continue
+		}
+
+		if timer, exists := fw.timers[id]; exists {
+			timer.Stop()
+		}
+
+		source := ws.source
+		fw.timers[id] = time.AfterFunc(watchDebounce, func() {
+			fw.mu.Lock()
+			delete(fw.timers, source.ID)
+			fw.mu.Unlock()
+
+			log.Printf("Detected change in local calendar '%s'", source.Name)
+			fw.onChange(source)
+		})
+	}
+}
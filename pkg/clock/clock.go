@@ -0,0 +1,193 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and makes timers. Code that decides what is due takes a
+// Clock instead of calling time.Now, so a schedule can be replayed at any time.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is a single-shot timer made by a Clock
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// Ticker is a repeating timer made by a Clock. Like a time.Ticker it drops ticks
+// that are not received in time.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real is the system clock
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{timer: time.NewTimer(d)}
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{ticker: time.NewTicker(d)}
+}
+
+type realTimer struct {
+	timer *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t realTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t realTicker) Stop() {
+	t.ticker.Stop()
+}
+
+// Simulated is a clock that only moves when Set is called. Its timers fire when the
+// clock is set to or past their deadline.
+type Simulated struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*simulatedTimer
+}
+
+// NewSimulated creates a Simulated clock reading start
+func NewSimulated(start time.Time) *Simulated {
+	return &Simulated{now: start}
+}
+
+// Now returns the simulated time
+func (s *Simulated) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.now
+}
+
+// NewTimer returns a timer that fires once the clock is set d past the current time
+func (s *Simulated) NewTimer(d time.Duration) Timer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := &simulatedTimer{clock: s, deadline: s.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- s.now
+		return t
+	}
+	s.timers = append(s.timers, t)
+	return t
+}
+
+// NewTicker returns a ticker that fires every d the clock is set forward. Like
+// time.NewTicker it panics if d is not positive.
+func (s *Simulated) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := &simulatedTimer{clock: s, deadline: s.now.Add(d), period: d, c: make(chan time.Time, 1)}
+	s.timers = append(s.timers, t)
+	return simulatedTicker{t}
+}
+
+// Set moves the clock to now, firing the timers and tickers that are due in deadline
+// order. A ticker fires once however many periods passed. Moving the clock backwards
+// is allowed and fires nothing.
+func (s *Simulated) Set(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.now = now
+
+	sort.Slice(s.timers, func(i, j int) bool {
+		return s.timers[i].deadline.Before(s.timers[j].deadline)
+	})
+	pending := s.timers[:0]
+	for _, t := range s.timers {
+		if t.deadline.After(now) {
+			pending = append(pending, t)
+			continue
+		}
+		if t.period == 0 {
+			t.c <- t.deadline
+			continue
+		}
+
+		select {
+		case t.c <- t.deadline:
+		default:
+			// The last tick was not received yet
+		}
+		for !t.deadline.After(now) {
+			t.deadline = t.deadline.Add(t.period)
+		}
+		pending = append(pending, t)
+	}
+	s.timers = pending
+}
+
+type simulatedTimer struct {
+	clock    *Simulated
+	deadline time.Time
+	period   time.Duration // Zero for a timer, the interval of a ticker
+	c        chan time.Time
+}
+
+func (t *simulatedTimer) C() <-chan time.Time {
+	return t.c
+}
+
+// Stop removes the timer from its clock, returning false if it already fired
+func (t *simulatedTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	for i, pending := range t.clock.timers {
+		if pending == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// simulatedTicker is a simulatedTimer with a period, re-armed by Set each time it fires
+type simulatedTicker struct {
+	timer *simulatedTimer
+}
+
+func (t simulatedTicker) C() <-chan time.Time {
+	return t.timer.c
+}
+
+func (t simulatedTicker) Stop() {
+	t.timer.Stop()
+}
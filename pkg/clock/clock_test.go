@@ -0,0 +1,78 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+// received returns the time waiting on c, if any
+func received(c <-chan time.Time) (time.Time, bool) {
+	select {
+	case at := <-c:
+		return at, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestSimulatedTimer(t *testing.T) {
+	clk := NewSimulated(start)
+	timer := clk.NewTimer(10 * time.Minute)
+
+	clk.Set(start.Add(9 * time.Minute))
+	if _, ok := received(timer.C()); ok {
+		t.Fatal("timer fired before its deadline")
+	}
+
+	clk.Set(start.Add(15 * time.Minute))
+	if at, ok := received(timer.C()); !ok || !at.Equal(start.Add(10*time.Minute)) {
+		t.Fatalf("timer fired at %v (%v), want its deadline %v", at, ok, start.Add(10*time.Minute))
+	}
+	if timer.Stop() {
+		t.Error("Stop() = true for a timer that fired")
+	}
+
+	stopped := clk.NewTimer(time.Minute)
+	if !stopped.Stop() {
+		t.Error("Stop() = false for a pending timer")
+	}
+	clk.Set(start.Add(time.Hour))
+	if _, ok := received(stopped.C()); ok {
+		t.Error("stopped timer fired")
+	}
+}
+
+func TestSimulatedTicker(t *testing.T) {
+	clk := NewSimulated(start)
+	ticker := clk.NewTicker(5 * time.Minute)
+
+	clk.Set(start.Add(5 * time.Minute))
+	if at, ok := received(ticker.C()); !ok || !at.Equal(start.Add(5*time.Minute)) {
+		t.Fatalf("first tick at %v (%v), want %v", at, ok, start.Add(5*time.Minute))
+	}
+
+	// Ticks skipped over arrive as one, and the ticker keeps its phase
+	clk.Set(start.Add(17 * time.Minute))
+	if at, ok := received(ticker.C()); !ok || !at.Equal(start.Add(10*time.Minute)) {
+		t.Fatalf("tick after a jump at %v (%v), want %v", at, ok, start.Add(10*time.Minute))
+	}
+	if _, ok := received(ticker.C()); ok {
+		t.Fatal("ticker queued more than one tick")
+	}
+	clk.Set(start.Add(19 * time.Minute))
+	if _, ok := received(ticker.C()); ok {
+		t.Fatal("ticker fired before its next period")
+	}
+	clk.Set(start.Add(20 * time.Minute))
+	if _, ok := received(ticker.C()); !ok {
+		t.Fatal("ticker did not fire at its next period")
+	}
+
+	ticker.Stop()
+	clk.Set(start.Add(time.Hour))
+	if _, ok := received(ticker.C()); ok {
+		t.Error("stopped ticker fired")
+	}
+}
@@ -4,25 +4,118 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/borgmon/focus-breaker/pkg/clock"
 )
 
 // Config holds application configuration
 type Config struct {
-	AutoStart        bool         `json:"auto_start"`
-	ICalSources      []ICalSource `json:"ical_sources"`
-	UpdateInterval   int          `json:"update_interval"`    // minutes
-	SnoozeTime       int          `json:"snooze_time"`        // minutes
-	NotifyUnaccepted bool         `json:"notify_unaccepted"`  // notify for unaccepted events
-	AlertBeforeMin   string       `json:"alert_before_min"`   // comma-separated minutes
-	HoldTimeSeconds  int          `json:"hold_time_seconds"`  // button hold time
-	QuietTimeRanges  []TimeRange  `json:"quiet_time_ranges"`  // quiet time ranges
+	AutoStart         bool                     `json:"auto_start"`
+	ICalSources       []ICalSource             `json:"ical_sources"`
+	UpdateInterval    int                      `json:"update_interval"`     // minutes
+	LookaheadDays     int                      `json:"lookahead_days"`      // how many days ahead events are fetched
+	SourceTimeout     int                      `json:"source_timeout"`      // seconds allowed per source fetch
+	StaleWarningHours int                      `json:"stale_warning_hours"` // warn when a source has failed this long (0 disables)
+	SnoozeTime        int                      `json:"snooze_time"`         // minutes
+	MissedAlertGrace  int                      `json:"missed_alert_grace"`  // minutes a missed alert is still shown late (0 disables)
+	Participation     ParticipationPolicy      `json:"participation"`       // which of my responses get alerts
+	AlertBeforeMin    string                   `json:"alert_before_min"`    // comma-separated minutes
+	AlarmSource       AlarmSource              `json:"alarm_source"`        // whose reminders schedule alerts
+	MeetingProviders  []MeetingProviderPattern `json:"meeting_providers"`   // custom meeting link patterns
+	NativeApps        []string                 `json:"native_apps"`         // providers whose links open in their desktop app
+	HoldTimeSeconds   int                      `json:"hold_time_seconds"`   // button hold time
+	QuietTimeRanges   []TimeRange              `json:"quiet_time_ranges"`   // quiet time ranges
+}
+
+// MeetingProviderPattern is a user-defined meeting provider recognized by a regex
+type MeetingProviderPattern struct {
+	Name    string `json:"name"`    // Label shown for matching links
+	Pattern string `json:"pattern"` // Regular expression matching a complete join URL
 }
 
+// AlarmSource selects which reminders schedule alerts for an event
+type AlarmSource string
+
+const (
+	AlarmSourceApp      AlarmSource = "app"      // Only the app's Alert Before times
+	AlarmSourceCalendar AlarmSource = "calendar" // Only VALARM reminders set on the event
+	AlarmSourceBoth     AlarmSource = "both"     // App times merged with the event's reminders
+)
+
+// SourceType identifies how events are retrieved for a calendar source
+type SourceType string
+
+const (
+	SourceTypeICal   SourceType = "ical"   // Plain iCal feed fetched over HTTP
+	SourceTypeCalDAV SourceType = "caldav" // CalDAV server queried with REPORT
+)
+
 // ICalSource represents a named iCal calendar source
 type ICalSource struct {
-	ID   string `json:"id"`   // Unique identifier
-	Name string `json:"name"` // Display name
-	URL  string `json:"url"`  // iCal URL
+	ID           string            `json:"id"`                      // Unique identifier
+	Name         string            `json:"name"`                    // Display name
+	URL          string            `json:"url"`                     // iCal URL, CalDAV server/calendar URL, or file:// path
+	Type         SourceType        `json:"type,omitempty"`          // Source type (empty means iCal)
+	Username     string            `json:"username,omitempty"`      // HTTP Basic auth / CalDAV username
+	Password     string            `json:"password,omitempty"`      // HTTP Basic auth / CalDAV password or app password
+	BearerToken  string            `json:"bearer_token,omitempty"`  // Sent as "Authorization: Bearer <token>"
+	Headers      map[string]string `json:"headers,omitempty"`       // Extra HTTP headers sent on every request
+	CalendarURLs []string          `json:"calendar_urls,omitempty"` // Selected CalDAV calendar collection URLs
+	MyEmails     []string          `json:"my_emails,omitempty"`     // My addresses, used to find my own ATTENDEE entry
+	OpenCommand  string            `json:"open_command,omitempty"`  // Browser or launcher for this source's meeting links (empty uses the default browser)
+	Filters      FilterRules       `json:"filters,omitzero"`        // Which of the source's events are alerted
+	Timezone     string            `json:"timezone,omitempty"`      // IANA zone the feed's floating times are written in (empty means local time)
+
+	AllDayAlertTime string `json:"all_day_alert_time,omitempty"` // "15:04" local time to alert all-day events at on their first day; empty skips them
+}
+
+// FilterRules selects which events of a source are alerted; empty rules keep everything
+type FilterRules struct {
+	IncludeTitle string   `json:"include_title,omitempty"` // Regex; only events whose title matches are kept
+	ExcludeTitle string   `json:"exclude_title,omitempty"` // Regex; events whose title matches are dropped
+	Organizers   []string `json:"organizers,omitempty"`    // Only events organized by one of these addresses or "@domain"s
+	Categories   []string `json:"categories,omitempty"`    // Only events in one of these categories
+	SkipFree     bool     `json:"skip_free,omitempty"`     // Drop events marked free (TRANSP:TRANSPARENT)
+	MaxAttendees int      `json:"max_attendees,omitempty"` // Drop events with more attendees than this (0 means no limit)
+}
+
+// IsEmpty returns true if no rule is set
+func (r FilterRules) IsEmpty() bool {
+	return r.IncludeTitle == "" && r.ExcludeTitle == "" && len(r.Organizers) == 0 &&
+		len(r.Categories) == 0 && !r.SkipFree && r.MaxAttendees == 0
+}
+
+// ParticipationPolicy decides, per participation status, whether an event is alerted
+type ParticipationPolicy struct {
+	Accepted    bool `json:"accepted"`
+	Tentative   bool `json:"tentative"`
+	Declined    bool `json:"declined"`
+	NeedsAction bool `json:"needs_action"` // Invitations I have not responded to
+}
+
+// DefaultParticipationPolicy alerts for accepted and tentative events only
+var DefaultParticipationPolicy = ParticipationPolicy{
+	Accepted:    true,
+	Tentative:   true,
+	Declined:    false,
+	NeedsAction: false,
+}
+
+// Allows returns true if events with the given response should be alerted; an
+// unknown response (no matching attendee) is always alerted
+func (p ParticipationPolicy) Allows(status PartStat) bool {
+	switch status {
+	case PartStatAccepted:
+		return p.Accepted
+	case PartStatTentative:
+		return p.Tentative
+	case PartStatDeclined:
+		return p.Declined
+	case PartStatNeedsAction:
+		return p.NeedsAction
+	default:
+		return true
+	}
 }
 
 // TimeRange represents a time range within a day
@@ -33,6 +126,34 @@ type TimeRange struct {
 	EndMinute   int `json:"end_minute"`   // 0-59
 }
 
+// OpensInNativeApp returns true if links of provider should open in its desktop app
+func (c *Config) OpensInNativeApp(provider string) bool {
+	for _, name := range c.NativeApps {
+		if name == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// GetStaleWarningThreshold returns how long a source may fail before a warning is
+// raised, or 0 if stale-source warnings are disabled
+func (c *Config) GetStaleWarningThreshold() time.Duration {
+	if c.StaleWarningHours <= 0 {
+		return 0
+	}
+	return time.Duration(c.StaleWarningHours) * time.Hour
+}
+
+// GetMissedAlertGrace returns how late an alert missed during sleep or a busy moment
+// is still shown, or 0 if missed alerts are dropped
+func (c *Config) GetMissedAlertGrace() time.Duration {
+	if c.MissedAlertGrace <= 0 {
+		return 0
+	}
+	return time.Duration(c.MissedAlertGrace) * time.Minute
+}
+
 // NeedsConfiguration returns true if the config needs initial setup
 func (c *Config) NeedsConfiguration() bool {
 	return len(c.ICalSources) == 0
@@ -64,9 +185,65 @@ func (c *Config) GetAlertMinutes() []int {
 	return minutes
 }
 
-// IsInQuietTime returns true if current time is in a quiet time range
-func (c *Config) IsInQuietTime() bool {
-	return c.IsTimeInQuietTime(time.Now())
+// AlertMinutesFor returns the minutes before start at which event is alerted, merging
+// appMinutes (from GetAlertMinutes) with the event's own alarms according to
+// AlarmSource. The alert at event start is always kept. All-day events are alerted
+// once, at their AlertAt time.
+func (c *Config) AlertMinutesFor(event *Event, appMinutes []int) []int {
+	if event.AllDay {
+		return []int{0}
+	}
+
+	switch c.AlarmSource {
+	case AlarmSourceApp:
+		return appMinutes
+	case AlarmSourceCalendar:
+		appMinutes = []int{0}
+	}
+
+	if len(event.AlarmMinutes) == 0 {
+		return appMinutes
+	}
+
+	minutes := []int{}
+	seen := make(map[int]bool)
+	for _, list := range [][]int{appMinutes, event.AlarmMinutes} {
+		for _, min := range list {
+			if min >= 0 && !seen[min] {
+				minutes = append(minutes, min)
+				seen[min] = true
+			}
+		}
+	}
+	return minutes
+}
+
+// GetLookahead returns how far ahead events are fetched. It is stretched when needed
+// so the earliest alert of an event just past the window can still fire before the
+// next sync brings that event in.
+func (c *Config) GetLookahead() time.Duration {
+	days := c.LookaheadDays
+	if days <= 0 {
+		days = 1
+	}
+	lookahead := time.Duration(days) * 24 * time.Hour
+
+	maxAlert := 0
+	for _, min := range c.GetAlertMinutes() {
+		if min > maxAlert {
+			maxAlert = min
+		}
+	}
+	if required := time.Duration(maxAlert+c.UpdateInterval) * time.Minute; required > lookahead {
+		lookahead = required
+	}
+
+	return lookahead
+}
+
+// IsInQuietTime returns true if the clock's current time is in a quiet time range
+func (c *Config) IsInQuietTime(clk clock.Clock) bool {
+	return c.IsTimeInQuietTime(clk.Now())
 }
 
 // IsTimeInQuietTime returns true if the given time is in a quiet time range
@@ -96,7 +273,27 @@ func (c *Config) IsTimeInQuietTime(t time.Time) bool {
 	return false
 }
 
+// IsMyEmail returns true if email is one of the source's MyEmails
+func (s *ICalSource) IsMyEmail(email string) bool {
+	for _, mine := range s.MyEmails {
+		if strings.EqualFold(strings.TrimSpace(mine), email) {
+			return true
+		}
+	}
+	return false
+}
+
 // Validate checks if the iCal source has required fields
 func (s *ICalSource) Validate() bool {
 	return s.Name != "" && s.URL != ""
 }
+
+// IsLocal returns true if the source points to a local .ics file or directory (file:// URL)
+func (s *ICalSource) IsLocal() bool {
+	return strings.HasPrefix(strings.ToLower(s.URL), "file://")
+}
+
+// IsCalDAV returns true if the source is a CalDAV server rather than a plain iCal feed
+func (s *ICalSource) IsCalDAV() bool {
+	return s.Type == SourceTypeCalDAV
+}
@@ -9,7 +9,118 @@ type Event struct {
 	Description string    // Event description
 	StartTime   time.Time // Event start time
 	EndTime     time.Time // Event end time
-	MeetingLink string    // Meeting link (Zoom, Google Meet, etc.)
+	MeetingLink string    // Best meeting link (Zoom, Google Meet, etc.), same as Conferences[0]
 	Status      string    // Event status (CONFIRMED, CANCELLED, NEEDS-ACTION)
 	SourceID    string    // ID of the iCal source this event came from
+	AllDay      bool      // DTSTART is a DATE rather than a DATE-TIME
+	AlertAt     time.Time // For all-day events, the local time to alert at instead of the start
+	Timezone    string    // IANA zone the event was written in ("" for local, UTC or custom zones)
+
+	Location     string   // LOCATION, e.g. a room or address
+	Categories   []string // CATEGORIES
+	URL          string   // URL property of the event
+	Transparency string   // TRANSP: OPAQUE (busy) or TRANSPARENT (free)
+	Priority     int      // PRIORITY: 1 (highest) to 9 (lowest), 0 if undefined
+	Class        string   // CLASS: PUBLIC, PRIVATE or CONFIDENTIAL
+
+	Conferences []Conference // Every meeting link found on the event, best first
+	DialIns     []DialIn     // Phone numbers to join the meeting by
+	MeetingID   string       // Meeting ID or conference ID from the invitation
+	Passcode    string       // Meeting passcode, password or PIN from the invitation
+
+	AlarmMinutes []int // Minutes before start at which the event's own VALARMs fire
+
+	Organizer Attendee   // ORGANIZER of the event (empty if none)
+	Attendees []Attendee // ATTENDEEs of the event
+	MyStatus  PartStat   // The user's own PARTSTAT, matched via the source's MyEmails ("" if unknown)
+}
+
+// FilteredEvent is an event within the alert window that was dropped while syncing
+type FilteredEvent struct {
+	Event  Event
+	Reason string // Why the event was dropped, e.g. "All-day event"
+}
+
+// Conference is a meeting link together with the provider it belongs to
+type Conference struct {
+	Provider string // e.g. "Zoom", "Google Meet", or "Link" for an unrecognized URL
+	URL      string
+}
+
+// DialIn is a phone number to join a meeting by
+type DialIn struct {
+	Number string // Dialable number, may end in a one-tap suffix like ",,123456789#"
+	Label  string // Where the number is, e.g. "US (New York)", if given
+}
+
+// PartStat is an attendee's participation status (PARTSTAT)
+type PartStat string
+
+const (
+	PartStatAccepted    PartStat = "ACCEPTED"
+	PartStatTentative   PartStat = "TENTATIVE"
+	PartStatDeclined    PartStat = "DECLINED"
+	PartStatNeedsAction PartStat = "NEEDS-ACTION"
+	PartStatDelegated   PartStat = "DELEGATED"
+)
+
+// Attendee is a participant of an event, taken from an ATTENDEE or ORGANIZER property
+type Attendee struct {
+	Email    string   // Address without the mailto: prefix
+	Name     string   // Common name (CN), if given
+	PartStat PartStat // Participation status (attendees only)
+	Role     string   // REQ-PARTICIPANT, OPT-PARTICIPANT, CHAIR, ...
+}
+
+// AlertBase returns the time alert offsets count back from: AlertAt when set, otherwise the start
+func (e *Event) AlertBase() time.Time {
+	if !e.AlertAt.IsZero() {
+		return e.AlertAt
+	}
+	return e.StartTime
+}
+
+// OriginalStart returns the start in the zone the event was written in, if that zone
+// is set and is at a different UTC offset than local time at the start
+func (e *Event) OriginalStart() (time.Time, bool) {
+	if e.Timezone == "" {
+		return time.Time{}, false
+	}
+	loc, err := time.LoadLocation(e.Timezone)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	original := e.StartTime.In(loc)
+	_, offset := original.Zone()
+	_, localOffset := e.StartTime.In(time.Local).Zone()
+	if offset == localOffset {
+		return time.Time{}, false
+	}
+	return original, true
+}
+
+// IsFree returns true if the event does not block time (TRANSP:TRANSPARENT)
+func (e *Event) IsFree() bool {
+	return e.Transparency == "TRANSPARENT"
+}
+
+// DisplayName returns the attendee's name, or the email address if there is none
+func (a Attendee) DisplayName() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	return a.Email
+}
+
+// MyResponse returns the user's response to the event. Feeds without attendee data
+// fall back to the event STATUS, which some servers set to NEEDS-ACTION.
+func (e *Event) MyResponse() PartStat {
+	if e.MyStatus != "" {
+		return e.MyStatus
+	}
+	if e.Status == string(PartStatNeedsAction) {
+		return PartStatNeedsAction
+	}
+	return ""
 }
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// SourceHealth tracks how syncing a calendar source has been going
+type SourceHealth struct {
+	SourceID            string    // ID of the iCal source
+	LastAttempt         time.Time // When the source was last synced
+	LastSuccess         time.Time // When the source last synced without error (zero if never)
+	LastError           string    // Error of the most recent failed sync (empty after a success)
+	ConsecutiveFailures int       // Failed syncs since the last success
+	FailingSince        time.Time // First failure since the last success (zero while healthy)
+	EventCount          int       // Events returned by the most recent sync that produced events
+	FeedEvents          int       // VEVENTs in the feed at the most recent successful sync (-1 if unknown)
+	EmptiedFrom         int       // VEVENTs the feed had before it suddenly came back empty (0 if not)
+	UsingCache          bool      // Events currently come from the on-disk cache
+}
+
+// IsHealthy returns true if the most recent sync succeeded
+func (h *SourceHealth) IsHealthy() bool {
+	return h.ConsecutiveFailures == 0 && !h.LastSuccess.IsZero()
+}
+
+// StaleReason explains why the source looks like it silently stopped syncing, or
+// returns "" if it does not. A threshold of 0 disables the check for long failures.
+func (h *SourceHealth) StaleReason(now time.Time, threshold time.Duration) string {
+	if h.EmptiedFrom > 0 {
+		return fmt.Sprintf("suddenly returned no events (previously %d)", h.EmptiedFrom)
+	}
+
+	if threshold <= 0 || h.ConsecutiveFailures == 0 {
+		return ""
+	}
+
+	since := h.LastSuccess
+	if since.IsZero() {
+		since = h.FailingSince
+	}
+	if since.IsZero() || now.Sub(since) < threshold {
+		return ""
+	}
+
+	if h.LastSuccess.IsZero() {
+		return fmt.Sprintf("has been failing since %s", since.Format("Mon Jan 2 3:04 PM"))
+	}
+	return fmt.Sprintf("has not synced since %s", since.Format("Mon Jan 2 3:04 PM"))
+}
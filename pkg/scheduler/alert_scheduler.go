@@ -0,0 +1,128 @@
+// Package scheduler decides when the alerts of an AlertStore are due
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/borgmon/focus-breaker/pkg/clock"
+	"github.com/borgmon/focus-breaker/pkg/models"
+	"github.com/borgmon/focus-breaker/pkg/store"
+)
+
+const (
+	// maxAlertWait caps how long the alert scheduler sleeps. Timers stand still while
+	// the computer sleeps, so this is how soon alerts missed during sleep are noticed.
+	maxAlertWait = time.Minute
+
+	// alertLateness is how late an alert may be noticed and still be shown on its own
+	// rather than as missed
+	alertLateness = time.Minute
+)
+
+// AlertScheduler decides when alerts are due. It sleeps until the next alert in the
+// store, is woken early by Rearm whenever events, alerts or config change, and hands
+// the alerts that came due to onDue: those due a moment ago, and separately those
+// missed within the grace period, e.g. while the computer was asleep.
+type AlertScheduler struct {
+	clock  clock.Clock
+	store  *store.AlertStore
+	config func() *models.Config
+	onDue  func(due, missed []models.ScheduledAlert)
+
+	rearm     chan struct{}
+	lastCheck time.Time // Only touched by the goroutine running the scheduler
+}
+
+// NewAlertScheduler creates a scheduler for the alerts in alertStore and makes the
+// store re-arm it on every change
+func NewAlertScheduler(clk clock.Clock, alertStore *store.AlertStore, config func() *models.Config, onDue func(due, missed []models.ScheduledAlert)) *AlertScheduler {
+	s := &AlertScheduler{
+		clock:  clk,
+		store:  alertStore,
+		config: config,
+		onDue:  onDue,
+		rearm:  make(chan struct{}, 1),
+	}
+	alertStore.SetOnChange(s.Rearm)
+	return s
+}
+
+// Rearm wakes the scheduler to look up the next alert again
+func (s *AlertScheduler) Rearm() {
+	select {
+	case s.rearm <- struct{}{}:
+	default:
+		// A wake-up is already pending
+	}
+}
+
+// NextCheck returns when the scheduler checks for due alerts next
+func (s *AlertScheduler) NextCheck() time.Time {
+	next := s.clock.Now().Add(maxAlertWait)
+	if alertTime, ok := s.store.NextAlertTime(s.lastCheck, s.config().Participation); ok && alertTime.Before(next) {
+		next = alertTime
+	}
+	return next
+}
+
+// Run checks for due alerts at each deadline until ctx is cancelled
+func (s *AlertScheduler) Run(ctx context.Context) {
+	for {
+		timer := s.clock.NewTimer(s.NextCheck().Sub(s.clock.Now()))
+		select {
+		case <-timer.C():
+			s.Check()
+		case <-s.rearm:
+			timer.Stop()
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// Check hands the alerts that came due since the last check to onDue
+func (s *AlertScheduler) Check() {
+	now := s.clock.Now()
+	since := s.lastCheck
+	s.lastCheck = now
+
+	config := s.config()
+	if config.NeedsConfiguration() {
+		return
+	}
+
+	// Alerts due a moment ago are shown on their own
+	onTimeSince := now.Add(-alertLateness)
+	if since.After(onTimeSince) {
+		onTimeSince = since
+	}
+	due := s.store.GetDueAlerts(onTimeSince, now, config.Participation)
+
+	// Older ones were missed; those of events alerted right now are folded into the live
+	// alert and marked handled, so they don't come back later
+	missed := []models.ScheduledAlert{}
+	if grace := config.GetMissedAlertGrace(); grace > 0 {
+		missedSince := now.Add(-grace)
+		if since.After(missedSince) {
+			missedSince = since
+		}
+
+		alerting := make(map[string]bool)
+		for _, alert := range due {
+			alerting[alert.EventID] = true
+		}
+		for _, alert := range s.store.GetDueAlerts(missedSince, onTimeSince, config.Participation) {
+			if alerting[alert.EventID] {
+				s.store.MarkAlertStatus(alert.EventID, alert.AlertOffset, models.AlertStatusAlerted, nil)
+				continue
+			}
+			missed = append(missed, alert)
+		}
+	}
+
+	if len(due) > 0 || len(missed) > 0 {
+		s.onDue(due, missed)
+	}
+}
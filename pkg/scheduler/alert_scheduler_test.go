@@ -0,0 +1,193 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/borgmon/focus-breaker/pkg/clock"
+	"github.com/borgmon/focus-breaker/pkg/models"
+	"github.com/borgmon/focus-breaker/pkg/store"
+)
+
+var start = time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+
+// at returns the time h:m:s on the test day
+func at(h, m, s int) time.Time {
+	return time.Date(2026, 10, 16, h, m, s, 0, time.UTC)
+}
+
+// dueCall is one call of a scheduler's onDue
+type dueCall struct {
+	due, missed []models.ScheduledAlert
+}
+
+// newTestScheduler creates a scheduler on clk over three events alerted 10 and 5
+// minutes before and at their start: early at 08:30, standup at 09:10 and review at
+// 09:30. Missed alerts are shown for 30 minutes.
+func newTestScheduler(t *testing.T, clk clock.Clock) (*AlertScheduler, *store.AlertStore, *[]dueCall) {
+	t.Helper()
+
+	config := &models.Config{
+		ICalSources:      []models.ICalSource{{ID: "work"}},
+		MissedAlertGrace: 30,
+		Participation:    models.DefaultParticipationPolicy,
+	}
+	alertStore := store.NewAlertStore(clk)
+	calls := &[]dueCall{}
+	s := NewAlertScheduler(clk, alertStore, func() *models.Config { return config }, func(due, missed []models.ScheduledAlert) {
+		*calls = append(*calls, dueCall{due: due, missed: missed})
+	})
+
+	events := []models.Event{}
+	for id, startTime := range map[string]time.Time{"early": at(8, 30, 0), "standup": at(9, 10, 0), "review": at(9, 30, 0)} {
+		events = append(events, models.Event{ID: id, SourceID: "work", Title: id, StartTime: startTime, EndTime: startTime.Add(30 * time.Minute)})
+	}
+	alertStore.UpdateEvents(events, []string{"work"}, []int{0, 5, 10})
+	return s, alertStore, calls
+}
+
+// alertIDs lists the alerts as "event offset" strings, e.g. "standup -5"
+func alertIDs(alerts []models.ScheduledAlert) []string {
+	ids := []string{}
+	for _, alert := range alerts {
+		ids = append(ids, fmt.Sprintf("%s %d", alert.EventID, alert.AlertOffset))
+	}
+	return ids
+}
+
+func TestAlertSchedulerDue(t *testing.T) {
+	clk := clock.NewSimulated(start)
+	s, _, calls := newTestScheduler(t, clk)
+
+	if next := s.NextCheck(); !next.Equal(start.Add(maxAlertWait)) {
+		t.Errorf("NextCheck() with no alert within a minute = %v, want %v", next, start.Add(maxAlertWait))
+	}
+
+	clk.Set(at(8, 19, 30))
+	s.Check()
+	if len(*calls) != 0 {
+		t.Fatalf("alerts before any was due: %+v", *calls)
+	}
+	if next := s.NextCheck(); !next.Equal(at(8, 20, 0)) {
+		t.Errorf("NextCheck() = %v, want the next alert at %v", next, at(8, 20, 0))
+	}
+
+	clk.Set(at(8, 20, 0))
+	s.Check()
+	if len(*calls) != 1 {
+		t.Fatalf("got %d onDue calls, want 1", len(*calls))
+	}
+	if due, missed := alertIDs((*calls)[0].due), (*calls)[0].missed; len(due) != 1 || due[0] != "early -10" || len(missed) != 0 {
+		t.Errorf("onDue(%v, %v), want the 10 minute alert of early due and none missed", due, alertIDs(missed))
+	}
+
+	// Nothing is repeated on the next check
+	clk.Set(at(8, 20, 30))
+	s.Check()
+	if len(*calls) != 1 {
+		t.Errorf("alert repeated: %+v", (*calls)[1:])
+	}
+}
+
+func TestAlertSchedulerMissed(t *testing.T) {
+	clk := clock.NewSimulated(start)
+	s, alertStore, calls := newTestScheduler(t, clk)
+
+	// The computer slept from 08:00 to 09:25:30
+	clk.Set(at(9, 25, 30))
+	s.Check()
+	if len(*calls) != 1 {
+		t.Fatalf("got %d onDue calls, want 1", len(*calls))
+	}
+
+	due, missed := alertIDs((*calls)[0].due), alertIDs((*calls)[0].missed)
+	if len(due) != 1 || due[0] != "review -5" {
+		t.Errorf("due = %v, want the 5 minute alert of review", due)
+	}
+	// early is past the grace period, and review is already alerting
+	want := []string{"standup -10", "standup -5", "standup 0"}
+	if len(missed) != len(want) {
+		t.Fatalf("missed = %v, want %v", missed, want)
+	}
+	for i := range want {
+		if missed[i] != want[i] {
+			t.Errorf("missed = %v, want %v", missed, want)
+			break
+		}
+	}
+
+	// The missed alert of review is folded into its live one rather than left pending
+	for _, alert := range alertStore.AlertsForEvent("review") {
+		if alert.AlertOffset != -10 {
+			continue
+		}
+		if alert.Status != models.AlertStatusAlerted {
+			t.Errorf("review -10 status = %v, want %v", alert.Status, models.AlertStatusAlerted)
+		}
+		return
+	}
+	t.Error("review has no 10 minute alert")
+}
+
+// timerClock is a Simulated clock that reports the duration of every timer made
+type timerClock struct {
+	*clock.Simulated
+	timers chan time.Duration
+}
+
+func (c timerClock) NewTimer(d time.Duration) clock.Timer {
+	timer := c.Simulated.NewTimer(d)
+	c.timers <- d
+	return timer
+}
+
+func TestAlertSchedulerRearm(t *testing.T) {
+	clk := timerClock{Simulated: clock.NewSimulated(start), timers: make(chan time.Duration)}
+	config := &models.Config{ICalSources: []models.ICalSource{{ID: "work"}}, Participation: models.DefaultParticipationPolicy}
+	alertStore := store.NewAlertStore(clk)
+	fired := make(chan []models.ScheduledAlert, 1)
+	s := NewAlertScheduler(clk, alertStore, func() *models.Config { return config }, func(due, missed []models.ScheduledAlert) {
+		fired <- due
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	nextTimer := func() time.Duration {
+		t.Helper()
+		select {
+		case d := <-clk.timers:
+			return d
+		case <-time.After(5 * time.Second):
+			t.Fatal("scheduler made no timer")
+			return 0
+		}
+	}
+
+	if d := nextTimer(); d != maxAlertWait {
+		t.Fatalf("first timer %v, want %v with no alerts", d, maxAlertWait)
+	}
+
+	// A new alert wakes the scheduler, which sleeps until it instead
+	alarm := &models.Event{ID: "manual-1", Title: "Tea", StartTime: at(8, 0, 30), EndTime: at(8, 0, 30)}
+	alertStore.AddManualAlert(alarm, alarm.StartTime)
+	if d := nextTimer(); d != 30*time.Second {
+		t.Fatalf("timer after rearm %v, want 30s until the new alert", d)
+	}
+
+	clk.Set(at(8, 0, 30))
+	select {
+	case due := <-fired:
+		if len(due) != 1 || due[0].EventID != "manual-1" {
+			t.Errorf("due = %v, want the manual alarm", alertIDs(due))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("alert not shown when its timer fired")
+	}
+	if d := nextTimer(); d != maxAlertWait {
+		t.Errorf("timer after the alert %v, want %v", d, maxAlertWait)
+	}
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/borgmon/focus-breaker/pkg/fsutil"
+	"github.com/borgmon/focus-breaker/pkg/models"
+)
+
+// alertStoreFile is the on-disk form of an AlertStore
+type alertStoreFile struct {
+	Events []*models.Event          `json:"events"`
+	Alerts []*models.ScheduledAlert `json:"alerts"`
+}
+
+// Load restores the events, alert statuses and snoozes saved at path, and saves
+// every later change there. A missing file leaves the store empty; an unreadable
+// one is reported and left on disk until the next save replaces it.
+func (as *AlertStore) Load(path string) error {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	as.path = path
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read alert state: %w", err)
+	}
+
+	saved := alertStoreFile{}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("failed to decode alert state: %w", err)
+	}
+
+	for _, event := range saved.Events {
+		if event != nil && event.ID != "" {
+			as.events[event.ID] = event
+		}
+	}
+	for _, alert := range saved.Alerts {
+		// Alerts of events that were not saved can never be shown
+		if alert == nil || as.events[alert.EventID] == nil {
+			continue
+		}
+		as.alertsById[generateAlertID(alert.EventID, alert.AlertOffset)] = alert
+		timeKey := models.RoundToMinute(alert.AlertTime).Unix()
+		as.alertsByTime[timeKey] = append(as.alertsByTime[timeKey], alert)
+	}
+
+	// Whatever went stale while the app was not running
+	as.cleanupOldAlerts(as.clock.Now().Add(-12 * time.Hour))
+
+	log.Printf("Restored %d event(s) and %d alert(s) from %s", len(as.events), len(as.alertsById), path)
+	return nil
+}
+
+// save writes the store to its file, if it has one. The caller must hold as.mu.
+func (as *AlertStore) save() {
+	if as.path == "" {
+		return
+	}
+
+	saved := alertStoreFile{
+		Events: make([]*models.Event, 0, len(as.events)),
+		Alerts: make([]*models.ScheduledAlert, 0, len(as.alertsById)),
+	}
+	for _, event := range as.events {
+		saved.Events = append(saved.Events, event)
+	}
+	for _, alert := range as.alertsById {
+		saved.Alerts = append(saved.Alerts, alert)
+	}
+
+	data, err := json.Marshal(saved)
+	if err != nil {
+		log.Printf("Warning: failed to encode alert state: %v", err)
+		return
+	}
+
+	if err := fsutil.WriteFileAtomic(as.path, data); err != nil {
+		log.Printf("Warning: failed to save alert state: %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/borgmon/focus-breaker/pkg/models"
+)
+
+// AlertQuery selects events and alerts from an AlertStore. Zero fields match everything.
+type AlertQuery struct {
+	From      time.Time            // Events ending at or after From, alerts due at or after From
+	To        time.Time            // Events starting before To, alerts due before To
+	SourceIDs []string             // Calendars the events come from
+	Statuses  []models.AlertStatus // Alert statuses; an event matches if one of its alerts does
+}
+
+// matchesEvent reports whether event is within the query's range and calendars
+func (q AlertQuery) matchesEvent(event *models.Event) bool {
+	if len(q.SourceIDs) > 0 && !slices.Contains(q.SourceIDs, event.SourceID) {
+		return false
+	}
+
+	end := event.EndTime
+	if end.Before(event.StartTime) {
+		end = event.StartTime
+	}
+	if !q.From.IsZero() && end.Before(q.From) {
+		return false
+	}
+	return q.To.IsZero() || event.StartTime.Before(q.To)
+}
+
+// matchesAlert reports whether alert is within the query's range and statuses.
+// Calendars are checked on the alert's event.
+func (q AlertQuery) matchesAlert(alert *models.ScheduledAlert) bool {
+	if len(q.Statuses) > 0 && !slices.Contains(q.Statuses, alert.Status) {
+		return false
+	}
+	if !q.From.IsZero() && alert.AlertTime.Before(q.From) {
+		return false
+	}
+	return q.To.IsZero() || alert.AlertTime.Before(q.To)
+}
+
+// Events returns copies of the events matching query sorted by start time, including
+// events that have no alerts left. With Statuses set, only events with an alert in
+// one of those statuses are returned.
+func (as *AlertStore) Events(query AlertQuery) []models.Event {
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+
+	withStatus := make(map[string]bool)
+	if len(query.Statuses) > 0 {
+		for _, alert := range as.alertsById {
+			if slices.Contains(query.Statuses, alert.Status) {
+				withStatus[alert.EventID] = true
+			}
+		}
+	}
+
+	result := []models.Event{}
+	for _, event := range as.events {
+		if !query.matchesEvent(event) || (len(query.Statuses) > 0 && !withStatus[event.ID]) {
+			continue
+		}
+		result = append(result, copyEvent(event))
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].StartTime.Before(result[j].StartTime)
+	})
+	return result
+}
+
+// Alerts returns copies of the alerts matching query sorted by alert time. The time
+// range applies to when the alerts are due, the calendars to their events.
+func (as *AlertStore) Alerts(query AlertQuery) []models.ScheduledAlert {
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+
+	result := []models.ScheduledAlert{}
+	for _, alert := range as.alertsById {
+		if !query.matchesAlert(alert) {
+			continue
+		}
+		if len(query.SourceIDs) > 0 {
+			event := as.events[alert.EventID]
+			if event == nil || !slices.Contains(query.SourceIDs, event.SourceID) {
+				continue
+			}
+		}
+		result = append(result, *alert)
+	}
+
+	sortAlerts(result)
+	return result
+}
+
+// AlertsForEvent returns copies of the alerts of an event sorted by alert time
+func (as *AlertStore) AlertsForEvent(eventID string) []models.ScheduledAlert {
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+
+	result := []models.ScheduledAlert{}
+	for _, alert := range as.alertsById {
+		if alert.EventID == eventID {
+			result = append(result, *alert)
+		}
+	}
+
+	sortAlerts(result)
+	return result
+}
+
+// sortAlerts sorts alerts by alert time, and alerts due at the same time by event and
+// offset so the order is stable between calls
+func sortAlerts(alerts []models.ScheduledAlert) {
+	sort.Slice(alerts, func(i, j int) bool {
+		if !alerts[i].AlertTime.Equal(alerts[j].AlertTime) {
+			return alerts[i].AlertTime.Before(alerts[j].AlertTime)
+		}
+		if alerts[i].EventID != alerts[j].EventID {
+			return alerts[i].EventID < alerts[j].EventID
+		}
+		return alerts[i].AlertOffset < alerts[j].AlertOffset
+	})
+}
+
+// copyEvent returns a copy of event that shares no slices with it
+func copyEvent(event *models.Event) models.Event {
+	copied := *event
+	copied.Categories = slices.Clone(event.Categories)
+	copied.Conferences = slices.Clone(event.Conferences)
+	copied.DialIns = slices.Clone(event.DialIns)
+	copied.AlarmMinutes = slices.Clone(event.AlarmMinutes)
+	copied.Attendees = slices.Clone(event.Attendees)
+	return copied
+}
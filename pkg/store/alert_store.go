@@ -2,10 +2,11 @@ package store
 
 import (
 	"fmt"
-	"sort"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/borgmon/focus-breaker/pkg/clock"
 	"github.com/borgmon/focus-breaker/pkg/models"
 	"github.com/google/uuid"
 )
@@ -23,33 +24,63 @@ type AlertStore struct {
 
 	// Map of alert ID to scheduled alert for quick lookup
 	alertsById map[string]*models.ScheduledAlert
+
+	// File the store is saved to after every change, empty to keep it in memory only
+	path string
+
+	// Called after every change, see SetOnChange
+	onChange func()
+
+	// Decides which alerts are past
+	clock clock.Clock
 }
 
-// NewAlertStore creates a new AlertStore instance
-func NewAlertStore() *AlertStore {
+// NewAlertStore creates a new AlertStore instance that tells the time by clk
+func NewAlertStore(clk clock.Clock) *AlertStore {
 	return &AlertStore{
+		clock:        clk,
 		events:       make(map[string]*models.Event),
 		alertsByTime: make(map[int64][]*models.ScheduledAlert),
 		alertsById:   make(map[string]*models.ScheduledAlert),
 	}
 }
 
+// SetOnChange registers a function called after events or alerts change, e.g. to
+// re-arm the alert timer. It is called with the store locked and must not use it.
+func (as *AlertStore) SetOnChange(onChange func()) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	as.onChange = onChange
+}
+
+// changed saves the store and reports the change. The caller must hold as.mu.
+func (as *AlertStore) changed() {
+	as.save()
+	if as.onChange != nil {
+		as.onChange()
+	}
+}
+
 // generateAlertID creates a unique ID for an alert
 func generateAlertID(eventID string, alertOffset int) string {
 	return fmt.Sprintf("%s-%d", eventID, alertOffset)
 }
 
 // UpdateEvents updates the alert store with new events from calendar sync
-func (as *AlertStore) UpdateEvents(newEvents []models.Event, alertMinutes []int) {
-	as.UpdateEventsWithConfig(newEvents, alertMinutes, nil)
+func (as *AlertStore) UpdateEvents(newEvents []models.Event, syncedSources []string, alertMinutes []int) {
+	as.UpdateEventsWithConfig(newEvents, syncedSources, alertMinutes, nil)
 }
 
-// UpdateEventsWithConfig updates the alert store with new events and applies quiet time config
-func (as *AlertStore) UpdateEventsWithConfig(newEvents []models.Event, alertMinutes []int, config *models.Config) {
+// UpdateEventsWithConfig updates the alert store with new events and applies quiet time
+// config. newEvents is the complete list of events of syncedSources, the sources that
+// were read: their upcoming events missing from it, e.g. cancelled instances or events
+// a filter now drops, are removed. Events of other sources are left alone.
+func (as *AlertStore) UpdateEventsWithConfig(newEvents []models.Event, syncedSources []string, alertMinutes []int, config *models.Config) {
 	as.mu.Lock()
 	defer as.mu.Unlock()
 
-	now := time.Now()
+	now := as.clock.Now()
 	cutoffTime := now.Add(-12 * time.Hour)
 
 	// Track which event IDs we've seen in this sync
@@ -64,44 +95,52 @@ func (as *AlertStore) UpdateEventsWithConfig(newEvents []models.Event, alertMinu
 		eventID := event.ID
 		seenEventIDs[eventID] = true
 
+		// Merge in reminders set on the event itself
+		eventMinutes := alertMinutes
+		if config != nil {
+			eventMinutes = config.AlertMinutesFor(&event, alertMinutes)
+		}
+
 		// Check if event exists
 		existingEvent, exists := as.events[eventID]
 
 		if exists {
-			// Update event details (but preserve alert statuses)
-			existingEvent.Title = event.Title
-			existingEvent.Description = event.Description
-			existingEvent.StartTime = event.StartTime
-			existingEvent.EndTime = event.EndTime
-			existingEvent.MeetingLink = event.MeetingLink
-			existingEvent.Status = event.Status
+			// Update event details (alert statuses live on the alerts and are preserved)
+			*existingEvent = event
 
 			// Update alert times if event time changed
-			as.updateAlertsForEvent(eventID, &event, alertMinutes)
+			as.updateAlertsForEvent(eventID, &event, eventMinutes)
 		} else {
 			// New event - add it and create alerts
 			as.events[eventID] = &event
-			as.createAlertsForEventWithConfig(eventID, &event, alertMinutes, config)
+			as.createAlertsForEventWithConfig(eventID, &event, eventMinutes, config)
 		}
 	}
 
-	// Remove events that are no longer in the calendar (but keep recent ones within 12 hours)
+	// Remove events that are no longer in the calendar: upcoming ones of the synced
+	// sources right away, past ones after 12 hours so they can still be looked up
 	for eventID, event := range as.events {
-		if !seenEventIDs[eventID] && event.StartTime.Before(now) && event.StartTime.Before(cutoffTime) {
+		if seenEventIDs[eventID] {
+			continue
+		}
+		upcoming := event.EndTime.After(now) && slices.Contains(syncedSources, event.SourceID)
+		if upcoming || event.StartTime.Before(cutoffTime) {
 			as.removeEvent(eventID)
 		}
 	}
 
 	// Clean up old events and alerts older than 12 hours
 	as.cleanupOldAlerts(cutoffTime)
+
+	as.changed()
 }
 
 // createAlertsForEventWithConfig creates all scheduled alerts for a new event, checking quiet time
 func (as *AlertStore) createAlertsForEventWithConfig(eventID string, event *models.Event, alertMinutes []int, config *models.Config) {
-	now := time.Now()
+	now := as.clock.Now()
 
 	for _, minutes := range alertMinutes {
-		alertTime := event.StartTime.Add(-time.Duration(minutes) * time.Minute)
+		alertTime := event.AlertBase().Add(-time.Duration(minutes) * time.Minute)
 
 		// Skip creating alerts in the past
 		if alertTime.Before(now) {
@@ -142,7 +181,7 @@ func (as *AlertStore) updateAlertsForEvent(eventID string, event *models.Event,
 			as.removeAlertFromTimeIndex(oldTimeKey, alertID)
 
 			// Update alert time
-			alert.AlertTime = event.StartTime.Add(-time.Duration(minutes) * time.Minute)
+			alert.AlertTime = event.AlertBase().Add(-time.Duration(minutes) * time.Minute)
 			// Keep existing Status (don't reset to Pending)
 
 			// Add to new time slot
@@ -150,10 +189,10 @@ func (as *AlertStore) updateAlertsForEvent(eventID string, event *models.Event,
 			as.alertsByTime[newTimeKey] = append(as.alertsByTime[newTimeKey], alert)
 		} else {
 			// Alert doesn't exist, create new one (happens when alertMinutes config changes)
-			alertTime := event.StartTime.Add(-time.Duration(minutes) * time.Minute)
+			alertTime := event.AlertBase().Add(-time.Duration(minutes) * time.Minute)
 
 			// Skip creating alerts in the past
-			now := time.Now()
+			now := as.clock.Now()
 			if alertTime.Before(now) {
 				continue
 			}
@@ -220,6 +259,7 @@ func (as *AlertStore) RemoveEvent(eventID string) {
 	as.mu.Lock()
 	defer as.mu.Unlock()
 	as.removeEvent(eventID)
+	as.changed()
 }
 
 // cleanupOldAlerts removes alerts older than cutoff time
@@ -246,33 +286,60 @@ func (as *AlertStore) cleanupOldAlerts(cutoffTime time.Time) {
 	}
 }
 
-// GetAlertsForCurrentMinute returns all alerts scheduled for the current minute
-func (as *AlertStore) GetAlertsForCurrentMinute(notifyUnaccepted bool) []*models.ScheduledAlert {
+// GetDueAlerts returns copies of the pending alerts due after from and up to and
+// including to, oldest first, skipping events whose response the participation policy
+// does not alert for
+func (as *AlertStore) GetDueAlerts(from, to time.Time, participation models.ParticipationPolicy) []models.ScheduledAlert {
 	as.mu.RLock()
 	defer as.mu.RUnlock()
 
-	now := time.Now()
-	timeKey := models.RoundToMinute(now).Unix()
+	fromKey := models.RoundToMinute(from).Unix()
+	toKey := models.RoundToMinute(to).Unix()
 
-	alerts := as.alertsByTime[timeKey]
-	result := make([]*models.ScheduledAlert, 0)
+	result := make([]models.ScheduledAlert, 0)
+	for timeKey, alerts := range as.alertsByTime {
+		if timeKey < fromKey || timeKey > toKey {
+			continue
+		}
 
-	for _, alert := range alerts {
-		// Skip if pending and event is unaccepted
-		if !notifyUnaccepted {
-			event := as.events[alert.EventID]
-			if event != nil && event.Status == "NEEDS-ACTION" {
+		for _, alert := range alerts {
+			if !alert.AlertTime.After(from) || alert.AlertTime.After(to) || !as.alertable(alert, participation) {
 				continue
 			}
+			result = append(result, *alert)
 		}
+	}
+
+	sortAlerts(result)
+	return result
+}
 
-		// Only return pending or snoozed alerts
-		if alert.Status == models.AlertStatusPending || alert.Status == models.AlertStatusSnoozed {
-			result = append(result, alert)
+// NextAlertTime returns when the first pending alert after after is due, or false if
+// there is none
+func (as *AlertStore) NextAlertTime(after time.Time, participation models.ParticipationPolicy) (time.Time, bool) {
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+
+	var next time.Time
+	for _, alert := range as.alertsById {
+		if !alert.AlertTime.After(after) || !as.alertable(alert, participation) {
+			continue
+		}
+		if next.IsZero() || alert.AlertTime.Before(next) {
+			next = alert.AlertTime
 		}
 	}
+	return next, !next.IsZero()
+}
 
-	return result
+// alertable reports whether alert is still to be shown: it is pending and the
+// participation policy alerts for my response to its event
+func (as *AlertStore) alertable(alert *models.ScheduledAlert, participation models.ParticipationPolicy) bool {
+	if alert.Status != models.AlertStatusPending {
+		return false
+	}
+	event := as.events[alert.EventID]
+	return event == nil || participation.Allows(event.MyResponse())
 }
 
 // MarkAlertStatus updates the status of an alert
@@ -287,7 +354,7 @@ func (as *AlertStore) MarkAlertStatus(eventID string, alertOffset int, status mo
 			alert.Status = models.AlertStatusSnoozed
 
 			// Calculate snooze minutes from now
-			snoozeMinutes := int(snoozedUntil.Sub(time.Now()).Minutes())
+			snoozeMinutes := int(snoozedUntil.Sub(as.clock.Now()).Minutes())
 			if snoozeMinutes < 0 {
 				snoozeMinutes = 0
 			}
@@ -311,37 +378,21 @@ func (as *AlertStore) MarkAlertStatus(eventID string, alertOffset int, status mo
 			// Just update status for non-snooze cases
 			alert.Status = status
 		}
+		as.changed()
 	}
 }
 
-// GetAllScheduledAlerts returns all scheduled alerts sorted by time
-func (as *AlertStore) GetAllScheduledAlerts() []*models.ScheduledAlert {
-	as.mu.RLock()
-	defer as.mu.RUnlock()
-
-	now := time.Now()
-	result := make([]*models.ScheduledAlert, 0, len(as.alertsById))
-
-	for _, alert := range as.alertsById {
-		// Include future alerts and recent past alerts (within 12 hours)
-		if alert.AlertTime.After(now.Add(-12 * time.Hour)) {
-			result = append(result, alert)
-		}
-	}
-
-	// Sort by alert time
-	sort.Slice(result, func(i, j int) bool {
-		return result[i].AlertTime.Before(result[j].AlertTime)
-	})
-	return result
-}
-
-// GetEvent returns an event by ID
+// GetEvent returns a copy of the event with the given ID, or nil if there is none
 func (as *AlertStore) GetEvent(eventID string) *models.Event {
 	as.mu.RLock()
 	defer as.mu.RUnlock()
 
-	return as.events[eventID]
+	event, ok := as.events[eventID]
+	if !ok {
+		return nil
+	}
+	copied := copyEvent(event)
+	return &copied
 }
 
 // AddManualAlert adds a manual event and its alert to the store
@@ -349,8 +400,9 @@ func (as *AlertStore) AddManualAlert(event *models.Event, alertTime time.Time) {
 	as.mu.Lock()
 	defer as.mu.Unlock()
 
-	// Add the event
-	as.events[event.ID] = event
+	// Add a copy of the event, so the caller can't change it behind the store's back
+	copied := copyEvent(event)
+	as.events[event.ID] = &copied
 
 	// Create the alert
 	alert := &models.ScheduledAlert{
@@ -367,6 +419,8 @@ func (as *AlertStore) AddManualAlert(event *models.Event, alertTime time.Time) {
 	// Add to time-based index
 	timeKey := models.RoundToMinute(alertTime).Unix()
 	as.alertsByTime[timeKey] = append(as.alertsByTime[timeKey], alert)
+
+	as.changed()
 }
 
 // UpdateMutedStatusForQuietTime checks all alerts and updates their muted status based on quiet time ranges
@@ -390,4 +444,6 @@ func (as *AlertStore) UpdateMutedStatusForQuietTime(config *models.Config) {
 			alert.Status = models.AlertStatusPending
 		}
 	}
+
+	as.changed()
 }
@@ -22,12 +22,16 @@ func (cs *ConfigStore) Load() *models.Config {
 	prefs := cs.app.Preferences()
 
 	config := &models.Config{
-		AutoStart:        prefs.BoolWithFallback("auto_start", false),
-		UpdateInterval:   prefs.IntWithFallback("update_interval", 30),
-		SnoozeTime:       prefs.IntWithFallback("snooze_time", 4),
-		NotifyUnaccepted: prefs.BoolWithFallback("notify_unaccepted", false),
-		AlertBeforeMin:   prefs.StringWithFallback("alert_before_min", "5,15"),
-		HoldTimeSeconds:  prefs.IntWithFallback("hold_time_seconds", 5),
+		AutoStart:         prefs.BoolWithFallback("auto_start", false),
+		UpdateInterval:    prefs.IntWithFallback("update_interval", 30),
+		LookaheadDays:     prefs.IntWithFallback("lookahead_days", 1),
+		SourceTimeout:     prefs.IntWithFallback("source_timeout", 30),
+		StaleWarningHours: prefs.IntWithFallback("stale_warning_hours", 6),
+		SnoozeTime:        prefs.IntWithFallback("snooze_time", 4),
+		MissedAlertGrace:  prefs.IntWithFallback("missed_alert_grace", 15),
+		AlertBeforeMin:    prefs.StringWithFallback("alert_before_min", "5,15"),
+		AlarmSource:       models.AlarmSource(prefs.StringWithFallback("alarm_source", string(models.AlarmSourceBoth))),
+		HoldTimeSeconds:   prefs.IntWithFallback("hold_time_seconds", 5),
 	}
 
 	// Load iCal sources from JSON string
@@ -40,6 +44,30 @@ func (cs *ConfigStore) Load() *models.Config {
 		config.ICalSources = []models.ICalSource{}
 	}
 
+	// Load the participation policy from JSON string; older configs only had the
+	// "notify unaccepted" switch, which maps to invitations without a response
+	config.Participation = models.DefaultParticipationPolicy
+	config.Participation.NeedsAction = prefs.BoolWithFallback("notify_unaccepted", false)
+	if participationJSON := prefs.String("participation"); participationJSON != "" {
+		if err := json.Unmarshal([]byte(participationJSON), &config.Participation); err != nil {
+			config.Participation = models.DefaultParticipationPolicy
+		}
+	}
+
+	// Load custom meeting providers from JSON string
+	if providersJSON := prefs.String("meeting_providers"); providersJSON != "" {
+		if err := json.Unmarshal([]byte(providersJSON), &config.MeetingProviders); err != nil {
+			config.MeetingProviders = nil
+		}
+	}
+
+	// Load native app providers from JSON string
+	if nativeAppsJSON := prefs.String("native_apps"); nativeAppsJSON != "" {
+		if err := json.Unmarshal([]byte(nativeAppsJSON), &config.NativeApps); err != nil {
+			config.NativeApps = nil
+		}
+	}
+
 	// Load quiet time ranges from JSON string
 	quietTimeJSON := prefs.String("quiet_time_ranges")
 	if quietTimeJSON != "" {
@@ -59,9 +87,13 @@ func (cs *ConfigStore) Save(config *models.Config) {
 
 	prefs.SetBool("auto_start", config.AutoStart)
 	prefs.SetInt("update_interval", config.UpdateInterval)
+	prefs.SetInt("lookahead_days", config.LookaheadDays)
+	prefs.SetInt("source_timeout", config.SourceTimeout)
+	prefs.SetInt("stale_warning_hours", config.StaleWarningHours)
 	prefs.SetInt("snooze_time", config.SnoozeTime)
-	prefs.SetBool("notify_unaccepted", config.NotifyUnaccepted)
+	prefs.SetInt("missed_alert_grace", config.MissedAlertGrace)
 	prefs.SetString("alert_before_min", config.AlertBeforeMin)
+	prefs.SetString("alarm_source", string(config.AlarmSource))
 	prefs.SetInt("hold_time_seconds", config.HoldTimeSeconds)
 
 	// Save iCal sources as JSON string
@@ -69,6 +101,21 @@ func (cs *ConfigStore) Save(config *models.Config) {
 		prefs.SetString("ical_sources", string(icalSourcesJSON))
 	}
 
+	// Save participation policy as JSON string
+	if participationJSON, err := json.Marshal(config.Participation); err == nil {
+		prefs.SetString("participation", string(participationJSON))
+	}
+
+	// Save custom meeting providers as JSON string
+	if providersJSON, err := json.Marshal(config.MeetingProviders); err == nil {
+		prefs.SetString("meeting_providers", string(providersJSON))
+	}
+
+	// Save native app providers as JSON string
+	if nativeAppsJSON, err := json.Marshal(config.NativeApps); err == nil {
+		prefs.SetString("native_apps", string(nativeAppsJSON))
+	}
+
 	// Save quiet time ranges as JSON string
 	if quietTimeJSON, err := json.Marshal(config.QuietTimeRanges); err == nil {
 		prefs.SetString("quiet_time_ranges", string(quietTimeJSON))
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/borgmon/focus-breaker/pkg/models"
+)
+
+// FilteredEventStore keeps, per calendar source, the events the last sync dropped
+type FilteredEventStore struct {
+	mu sync.RWMutex
+
+	// Map of source ID to the events dropped from it
+	filtered map[string][]models.FilteredEvent
+}
+
+// NewFilteredEventStore creates a new FilteredEventStore instance
+func NewFilteredEventStore() *FilteredEventStore {
+	return &FilteredEventStore{
+		filtered: make(map[string][]models.FilteredEvent),
+	}
+}
+
+// Set replaces the dropped events of a source
+func (fs *FilteredEventStore) Set(sourceID string, filtered []models.FilteredEvent) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.filtered[sourceID] = filtered
+}
+
+// GetAll returns a copy of the dropped events of all sources, sorted by start time
+func (fs *FilteredEventStore) GetAll() []models.FilteredEvent {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	all := []models.FilteredEvent{}
+	for _, filtered := range fs.filtered {
+		all = append(all, filtered...)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Event.StartTime.Before(all[j].Event.StartTime)
+	})
+	return all
+}
+
+// Prune removes the dropped events of sources that are no longer configured
+func (fs *FilteredEventStore) Prune(sources []models.ICalSource) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	keep := make(map[string]bool)
+	for _, source := range sources {
+		keep[source.ID] = true
+	}
+	for sourceID := range fs.filtered {
+		if !keep[sourceID] {
+			delete(fs.filtered, sourceID)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"sync"
+	"time"
+
+	"github.com/borgmon/focus-breaker/pkg/clock"
+	"github.com/borgmon/focus-breaker/pkg/models"
+)
+
+// emptyFeedWarningMin is how many events a feed must have had before coming back
+// empty is treated as suspicious rather than a quiet calendar
+const emptyFeedWarningMin = 5
+
+// SyncHealthStore records the sync health of every calendar source
+type SyncHealthStore struct {
+	mu sync.RWMutex
+
+	// Map of source ID to its health
+	health map[string]*models.SourceHealth
+
+	// Stamps sync attempts
+	clock clock.Clock
+}
+
+// NewSyncHealthStore creates a new SyncHealthStore instance that tells the time by clk
+func NewSyncHealthStore(clk clock.Clock) *SyncHealthStore {
+	return &SyncHealthStore{
+		clock:  clk,
+		health: make(map[string]*models.SourceHealth),
+	}
+}
+
+// entry returns the health record of a source, creating it if needed
+func (hs *SyncHealthStore) entry(sourceID string) *models.SourceHealth {
+	h, exists := hs.health[sourceID]
+	if !exists {
+		h = &models.SourceHealth{SourceID: sourceID}
+		hs.health[sourceID] = h
+	}
+	return h
+}
+
+// RecordSuccess marks a successful sync that returned eventCount events out of
+// feedEvents events in the whole feed, or -1 if the size of the feed is unknown
+func (hs *SyncHealthStore) RecordSuccess(sourceID string, eventCount, feedEvents int) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	now := hs.clock.Now()
+	h := hs.entry(sourceID)
+
+	// A feed that had plenty of events and is now completely empty was most
+	// likely revoked or reset rather than cleared by hand
+	if feedEvents == 0 && h.FeedEvents >= emptyFeedWarningMin {
+		h.EmptiedFrom = h.FeedEvents
+	} else if feedEvents != 0 {
+		h.EmptiedFrom = 0
+	}
+
+	h.LastAttempt = now
+	h.LastSuccess = now
+	h.LastError = ""
+	h.ConsecutiveFailures = 0
+	h.FailingSince = time.Time{}
+	h.EventCount = eventCount
+	h.FeedEvents = feedEvents
+	h.UsingCache = false
+}
+
+// RecordFailure marks a failed sync; cachedEvents is the number of events served
+// from the on-disk cache instead, or -1 if the source contributed nothing, and
+// cachedAt is when that cached copy was fetched
+func (hs *SyncHealthStore) RecordFailure(sourceID string, err error, cachedEvents int, cachedAt time.Time) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	now := hs.clock.Now()
+	h := hs.entry(sourceID)
+	h.LastAttempt = now
+	h.LastError = err.Error()
+	if h.ConsecutiveFailures == 0 {
+		h.FailingSince = now
+	}
+	h.ConsecutiveFailures++
+
+	// After a restart the cache is the only record of when the source last worked
+	if h.LastSuccess.IsZero() && !cachedAt.IsZero() {
+		h.LastSuccess = cachedAt
+	}
+	h.UsingCache = cachedEvents >= 0
+	if cachedEvents >= 0 {
+		h.EventCount = cachedEvents
+	}
+}
+
+// Get returns a copy of the health of a source, or nil if it was never synced
+func (hs *SyncHealthStore) Get(sourceID string) *models.SourceHealth {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+
+	h, exists := hs.health[sourceID]
+	if !exists {
+		return nil
+	}
+	copied := *h
+	return &copied
+}
+
+// Prune forgets sources that are no longer configured
+func (hs *SyncHealthStore) Prune(sources []models.ICalSource) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	keep := make(map[string]bool)
+	for _, source := range sources {
+		keep[source.ID] = true
+	}
+	for sourceID := range hs.health {
+		if !keep[sourceID] {
+			delete(hs.health, sourceID)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"fyne.io/fyne/v2/app"
+	"github.com/borgmon/focus-breaker/pkg/calendar"
+	"github.com/borgmon/focus-breaker/pkg/clock"
+	"github.com/borgmon/focus-breaker/pkg/models"
+	"github.com/borgmon/focus-breaker/pkg/scheduler"
+	"github.com/borgmon/focus-breaker/pkg/store"
+)
+
+// simulateAtLayout is the format of --simulate-at, read in local time
+const simulateAtLayout = "2006-01-02 15:04"
+
+// simulateFromCommandLine runs a simulation if args ask for one and returns true,
+// or returns false to start the app normally
+func simulateFromCommandLine(args []string) bool {
+	flags := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	simulateAt := flags.String("simulate-at", "", `replay the .ics file given as argument from this local time ("`+simulateAtLayout+`") and print the alerts it would fire`)
+	simulateFor := flags.Duration("simulate-for", 24*time.Hour, "how far to fast-forward with --simulate-at")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return true
+		}
+		// Launchers may pass arguments of their own, the app starts anyway
+		log.Printf("Ignoring command line: %v", err)
+		return false
+	}
+	if *simulateAt == "" {
+		return false
+	}
+
+	start, err := time.ParseInLocation(simulateAtLayout, *simulateAt, time.Local)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --simulate-at %q, expected a time like %q\n", *simulateAt, simulateAtLayout)
+		os.Exit(2)
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "--simulate-at needs the .ics file to replay as argument")
+		os.Exit(2)
+	}
+
+	// The alert settings are the user's own
+	config := store.NewConfigStore(app.New()).Load()
+
+	// Sync and scheduler logs would drown the report
+	log.SetOutput(io.Discard)
+
+	if err := simulate(config, flags.Arg(0), start, *simulateFor, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Simulation failed: %v\n", err)
+		os.Exit(1)
+	}
+	return true
+}
+
+// simulationEntry is one line of the simulation report
+type simulationEntry struct {
+	at   time.Time
+	kind string
+	text string
+}
+
+// simulate replays the calendar file at path through the alert scheduler from start
+// for span, fast-forwarding from one alert to the next, and writes every alert that
+// would have been shown to out, together with the events that would not have been
+// alerted and why. The file stands in for the configured calendars; if one of them
+// is this file, its filters and email addresses are used.
+func simulate(config *models.Config, path string, start time.Time, span time.Duration, out io.Writer) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(absPath); err != nil {
+		return fmt.Errorf("cannot read calendar file: %w", err)
+	}
+
+	source := models.ICalSource{ID: "simulation", Name: filepath.Base(absPath), URL: "file://" + filepath.ToSlash(absPath)}
+	for _, configured := range config.ICalSources {
+		if configured.IsLocal() {
+			if configuredPath, err := calendar.LocalPath(configured.URL); err == nil && configuredPath == absPath {
+				source = configured
+			}
+		}
+	}
+	simConfig := *config
+	simConfig.ICalSources = []models.ICalSource{source}
+
+	clk := clock.NewSimulated(start)
+	alertStore := store.NewAlertStore(clk)
+
+	entries := []simulationEntry{}
+	fired := 0
+	report := func(kind string, alerts []models.ScheduledAlert) {
+		for _, alert := range alerts {
+			if event := alertStore.GetEvent(alert.EventID); event != nil {
+				entries = append(entries, simulationEntry{at: clk.Now(), kind: kind, text: describeSimulatedAlert(event, &alert)})
+				fired++
+			}
+			// Nobody is there to dismiss it
+			alertStore.MarkAlertStatus(alert.EventID, alert.AlertOffset, models.AlertStatusAlerted, nil)
+		}
+	}
+	alertScheduler := scheduler.NewAlertScheduler(clk, alertStore, func() *models.Config { return &simConfig }, func(due, missed []models.ScheduledAlert) {
+		report("alert", due)
+		report("missed", missed)
+	})
+
+	// Events and alerts that will not be shown are reported once, when first seen
+	reported := make(map[string]bool)
+	end := start.Add(span)
+	syncFile := func() error {
+		result, err := calendar.FetchEvents(context.Background(), clk, source, nil, simConfig.GetLookahead())
+		if err != nil {
+			return err
+		}
+
+		for _, filtered := range result.Filtered {
+			if key := "filtered " + filtered.Event.ID; !reported[key] && filtered.Event.StartTime.Before(end) {
+				reported[key] = true
+				entries = append(entries, simulationEntry{at: filtered.Event.StartTime, kind: "skipped", text: fmt.Sprintf("%s - %s", filtered.Event.Title, filtered.Reason)})
+			}
+		}
+		for _, event := range result.Events {
+			if response := event.MyResponse(); !simConfig.Participation.Allows(response) {
+				if key := "response " + event.ID; !reported[key] && event.StartTime.Before(end) {
+					reported[key] = true
+					entries = append(entries, simulationEntry{at: event.StartTime, kind: "skipped", text: fmt.Sprintf("%s - responded %s", event.Title, response)})
+				}
+			}
+		}
+
+		alertStore.UpdateEventsWithConfig(result.Events, []string{source.ID}, simConfig.GetAlertMinutes(), &simConfig)
+
+		for _, alert := range alertStore.Alerts(store.AlertQuery{From: clk.Now(), To: end, Statuses: []models.AlertStatus{models.AlertStatusMuted}}) {
+			key := fmt.Sprintf("muted %s %d", alert.EventID, alert.AlertOffset)
+			if event := alertStore.GetEvent(alert.EventID); event != nil && !reported[key] {
+				reported[key] = true
+				entries = append(entries, simulationEntry{at: alert.AlertTime, kind: "muted", text: describeSimulatedAlert(event, &alert) + " (quiet time)"})
+			}
+		}
+		return nil
+	}
+
+	// Sync on the app's schedule and check for alerts whenever the scheduler would
+	syncInterval := time.Duration(max(simConfig.UpdateInterval, 1)) * time.Minute
+	nextSync := start
+	for {
+		nextCheck := alertScheduler.NextCheck()
+		if !nextSync.After(nextCheck) && !nextSync.After(end) {
+			clk.Set(nextSync)
+			if err := syncFile(); err != nil {
+				return err
+			}
+			nextSync = nextSync.Add(syncInterval)
+			continue
+		}
+		if nextCheck.After(end) {
+			break
+		}
+		clk.Set(nextCheck)
+		alertScheduler.Check()
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].at.Before(entries[j].at)
+	})
+
+	fmt.Fprintf(out, "Simulating %s from %s to %s\n", source.Name, start.Format("Mon Jan 2 15:04"), end.Format("Mon Jan 2 15:04"))
+	for _, entry := range entries {
+		fmt.Fprintf(out, "%s  %-7s  %s\n", entry.at.Format("Mon Jan 2 15:04"), entry.kind, entry.text)
+	}
+	fmt.Fprintf(out, "%d alert(s) would have been shown\n", fired)
+	return nil
+}
+
+// describeSimulatedAlert describes an alert for the simulation report, e.g.
+// "Standup - 5 min before, starts 10:00"
+func describeSimulatedAlert(event *models.Event, alert *models.ScheduledAlert) string {
+	when := "at start"
+	if before := event.AlertBase().Sub(alert.AlertTime).Round(time.Minute); before > 0 {
+		when = fmt.Sprintf("%d min before", int(before.Minutes()))
+	}
+	return fmt.Sprintf("%s - %s, starts %s", event.Title, when, event.StartTime.Format("15:04"))
+}
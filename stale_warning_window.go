@@ -0,0 +1,44 @@
+package main
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showStaleWarning opens a small window listing calendars that stopped syncing. Unlike
+// meeting alerts it is not full screen and does not take focus, but it stays until
+// dismissed, so it is seen even with notifications muted.
+func (fb *FocusBreaker) showStaleWarning(warnings []string) {
+	fyne.Do(func() {
+		window := fb.app.NewWindow("Calendar Stopped Syncing")
+
+		message := widget.NewLabel(strings.Join(warnings, "\n") +
+			"\n\nMeeting alerts from these calendars may be missing until this is fixed.")
+		message.Wrapping = fyne.TextWrapWord
+
+		settingsButton := widget.NewButtonWithIcon("Open Settings", theme.SettingsIcon(), func() {
+			window.Close()
+			fb.showConfigWindow()
+		})
+		settingsButton.Importance = widget.HighImportance
+		dismissButton := widget.NewButton("Dismiss", func() {
+			window.Close()
+		})
+
+		window.SetContent(container.NewPadded(container.NewBorder(
+			nil,
+			container.NewHBox(layout.NewSpacer(), dismissButton, settingsButton),
+			widget.NewIcon(theme.WarningIcon()),
+			nil,
+			message,
+		)))
+		window.Resize(fyne.NewSize(480, 180))
+		window.CenterOnScreen()
+		window.Show()
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/driver/desktop"
 	"github.com/borgmon/focus-breaker/pkg/models"
+	"github.com/borgmon/focus-breaker/pkg/store"
 )
 
 func (fb *FocusBreaker) setupSystemTray() {
@@ -17,6 +18,17 @@ func (fb *FocusBreaker) updateSystemTrayMenu() {
 	if desk, ok := fb.app.(desktop.App); ok {
 		menuItems := []*fyne.MenuItem{}
 
+		var statusItem *fyne.MenuItem
+		staleSources := 0
+		if len(fb.config.ICalSources) > 0 {
+			statusItem, staleSources = fb.buildCalendarStatusMenuItem()
+		}
+
+		// A calendar that stopped syncing means missing alerts - put the warning first
+		if staleSources > 0 {
+			menuItems = append(menuItems, statusItem, fyne.NewMenuItemSeparator())
+		}
+
 		// Add upcoming alerts section at the top
 		upcomingAlerts := fb.getUpcomingTodayAlerts(5)
 		if len(upcomingAlerts) > 0 {
@@ -42,6 +54,11 @@ func (fb *FocusBreaker) updateSystemTrayMenu() {
 			menuItems = append(menuItems, fyne.NewMenuItemSeparator())
 		}
 
+		// Add calendar sync health
+		if statusItem != nil && staleSources == 0 {
+			menuItems = append(menuItems, statusItem, fyne.NewMenuItemSeparator())
+		}
+
 		// Add settings and sync below
 		menuItems = append(menuItems,
 			fyne.NewMenuItem("Settings", func() {
@@ -63,30 +80,64 @@ func (fb *FocusBreaker) updateSystemTrayMenu() {
 	}
 }
 
-// getUpcomingTodayAlerts returns the next N alerts scheduled for today
-func (fb *FocusBreaker) getUpcomingTodayAlerts(limit int) []*models.ScheduledAlert {
-	now := time.Now()
-	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	todayEnd := todayStart.Add(24 * time.Hour)
-
-	allAlerts := fb.alertStore.GetAllScheduledAlerts()
-	upcomingToday := []*models.ScheduledAlert{}
-
-	for _, alert := range allAlerts {
-		// Only include pending or snoozed alerts
-		if alert.Status != models.AlertStatusPending && alert.Status != models.AlertStatusSnoozed {
-			continue
+// buildCalendarStatusMenuItem returns a menu item summarizing source health, with
+// one child entry per source, and the number of sources that look stale
+func (fb *FocusBreaker) buildCalendarStatusMenuItem() (*fyne.MenuItem, int) {
+	failing := 0
+	stale := 0
+	children := []*fyne.MenuItem{}
+
+	now := fb.clock.Now()
+	threshold := fb.config.GetStaleWarningThreshold()
+	for _, source := range fb.config.ICalSources {
+		health := fb.healthStore.Get(source.ID)
+		if health != nil && !health.IsHealthy() {
+			failing++
 		}
 
-		// Only include alerts from now until end of today
-		if alert.AlertTime.After(now) && alert.AlertTime.Before(todayEnd) {
-			upcomingToday = append(upcomingToday, alert)
-			if len(upcomingToday) >= limit {
-				break
+		status := formatSourceHealth(health)
+		if health != nil {
+			if reason := health.StaleReason(now, threshold); reason != "" {
+				stale++
+				status = "Stale - " + reason
 			}
 		}
+
+		childItem := fyne.NewMenuItem(fmt.Sprintf("%s: %s",
+			truncateString(source.Name, 25), truncateString(status, 60)), nil)
+		childItem.Disabled = true
+		children = append(children, childItem)
+	}
+
+	label := "Calendars: All OK"
+	switch {
+	case stale > 0:
+		label = fmt.Sprintf("⚠ Calendars: %d stale - alerts may be missing", stale)
+	case failing > 0:
+		label = fmt.Sprintf("Calendars: %d failing", failing)
+	}
+
+	statusItem := fyne.NewMenuItem(label, nil)
+	if len(children) > 0 {
+		statusItem.ChildMenu = fyne.NewMenu("", children...)
 	}
+	return statusItem, stale
+}
 
+// getUpcomingTodayAlerts returns the next N alerts scheduled for today
+func (fb *FocusBreaker) getUpcomingTodayAlerts(limit int) []models.ScheduledAlert {
+	now := fb.clock.Now()
+	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	// Only pending or snoozed alerts from now until end of today
+	upcomingToday := fb.alertStore.Alerts(store.AlertQuery{
+		From:     now,
+		To:       todayStart.Add(24 * time.Hour),
+		Statuses: []models.AlertStatus{models.AlertStatusPending, models.AlertStatusSnoozed},
+	})
+	if len(upcomingToday) > limit {
+		upcomingToday = upcomingToday[:limit]
+	}
 	return upcomingToday
 }
 